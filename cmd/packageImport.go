@@ -1,11 +1,13 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 
 	"github.com/nikoksr/proji/pkg/domain"
 	packagestore "github.com/nikoksr/proji/pkg/package/store"
+	projectservice "github.com/nikoksr/proji/pkg/project/service"
 
 	"github.com/nikoksr/proji/internal/statuswriter"
 
@@ -68,12 +70,16 @@ func newPackageImportCommand() *packageImportCommand {
 				return errors.Wrap(err, "compile regex exclude")
 			}
 
+			// Cancel running hooks if the user interrupts proji
+			ctx, cancel := newInterruptContext()
+			defer cancel()
+
 			// Import configs
 			sw := statuswriter.New()
 			sw.Run()
 			for importType, paths := range importTypes {
 				for _, path := range paths {
-					go importPackage(sw.NewSink(), path, importType, regexExclude)
+					go importPackage(ctx, sw.NewSink(), path, importType, regexExclude)
 				}
 			}
 			sw.Wait()
@@ -93,7 +99,7 @@ func newPackageImportCommand() *packageImportCommand {
 	return &packageImportCommand{cmd: cmd}
 }
 
-func importPackage(status *statuswriter.Sink, path, importType string, exclude *regexp.Regexp) {
+func importPackage(ctx context.Context, status *statuswriter.Sink, path, importType string, exclude *regexp.Regexp) {
 	defer status.Close()
 	var pkg *domain.Package
 	var err error
@@ -110,7 +116,7 @@ func importPackage(status *statuswriter.Sink, path, importType string, exclude *
 	case flagPackage:
 		pkg, err = importPackageFromRemote(status, path)
 	case flagCollection:
-		importPackagesFromCollection(status, path, exclude)
+		importPackagesFromCollection(ctx, status, path, exclude)
 		return
 	default:
 		err = fmt.Errorf("import type %s not supported", importType)
@@ -123,12 +129,28 @@ func importPackage(status *statuswriter.Sink, path, importType string, exclude *
 		status.Write(message.Serrorf(err, "failed to import package from %s %s", importType, path))
 		return
 	}
-	if importType != flagCollection {
+	if importType != flagCollection && runPackageImportedHook(ctx, status, pkg) {
 		// Collections handle messages on its own
 		status.Write(message.Ssuccessf("successfully imported package %s [%s]", pkg.Name, pkg.Label))
 	}
 }
 
+// runPackageImportedHook runs the plugins that the given package registered for the package-imported hook. Their output
+// is only written to the plugin log, the status line is reserved for the import itself. It reports whether the hook
+// succeeded.
+func runPackageImportedHook(ctx context.Context, status *statuswriter.Sink, pkg *domain.Package) bool {
+	err := session.projectService.RunPackageHook(ctx, session.config.BasePath, domain.HookPackageImported, pkg, nil)
+	if err == nil {
+		return true
+	}
+	var pluginErr *projectservice.PluginError
+	if errors.As(err, &pluginErr) && pluginErr.LogPath != "" {
+		err = fmt.Errorf("%v, see %s", err, pluginErr.LogPath)
+	}
+	status.Write(message.Serrorf(err, "imported package %s [%s] but its %s hook failed", pkg.Name, pkg.Label, domain.HookPackageImported))
+	return false
+}
+
 func importPackageFromConfig(status *statuswriter.Sink, path string) (*domain.Package, error) {
 	// Import the package
 	pkg, err := session.packageService.ImportPackageFromConfig(path)
@@ -204,7 +226,7 @@ func importPackageFromRemote(status *statuswriter.Sink, url string) (*domain.Pac
 	return pkg, err
 }
 
-func importPackagesFromCollection(status *statuswriter.Sink, url string, exclude *regexp.Regexp) {
+func importPackagesFromCollection(ctx context.Context, status *statuswriter.Sink, url string, exclude *regexp.Regexp) {
 	// Parse url string to object
 	status.Write(message.Sinfof("parsing url"))
 	parsedURL, err := remote.ParseURL(url)
@@ -232,7 +254,7 @@ func importPackagesFromCollection(status *statuswriter.Sink, url string, exclude
 		}
 		if err != nil {
 			status.Write(message.Serrorf(err, "failed to store package %s [%s]", pkg.Name, pkg.Label))
-		} else {
+		} else if runPackageImportedHook(ctx, status, pkg) {
 			status.Write(message.Ssuccessf("successfully imported package %s [%s]", pkg.Name, pkg.Label))
 			successfulImports++
 		}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/jedib0t/go-pretty/v6/text"
@@ -50,9 +51,10 @@ func showPackage(preloadedPackage *domain.Package, label string) error {
 		}
 	}
 	output := os.Stdout
-	showBasicInfo(preloadedPackage.Name, preloadedPackage.Label, preloadedPackage.Description)
+	showBasicInfo(preloadedPackage)
 	showTemplates(output, preloadedPackage.Templates)
 	showPlugins(output, preloadedPackage.Plugins)
+	showSteps(output, preloadedPackage.Steps)
 	return nil
 }
 
@@ -70,10 +72,16 @@ func showPackages(labels ...string) error {
 	return nil
 }
 
-func showBasicInfo(name, label, description string) {
-	fmt.Printf("\nName:  %s\n", name)
-	fmt.Printf("Label: %s\n", label)
-	fmt.Printf("Description: %s\n\n", text.WrapSoft(description, session.maxTableColumnWidth))
+func showBasicInfo(pkg *domain.Package) {
+	fmt.Printf("\nName:  %s\n", pkg.Name)
+	fmt.Printf("Label: %s\n", pkg.Label)
+	if pkg.Version != "" {
+		fmt.Printf("Version: %s\n", pkg.Version)
+	}
+	if len(pkg.Requires) > 0 {
+		fmt.Printf("Requires: %s\n", strings.Join(pkg.Requires, ", "))
+	}
+	fmt.Printf("Description: %s\n\n", text.WrapSoft(pkg.Description, session.maxTableColumnWidth))
 }
 
 func showTemplates(out io.Writer, templates []*domain.Template) {
@@ -97,16 +105,37 @@ func showTemplates(out io.Writer, templates []*domain.Template) {
 func showPlugins(out io.Writer, plugins []*domain.Plugin) {
 	pluginsTable := util.NewInfoTable(out)
 	pluginsTable.SetTitle("PLUGINS")
-	pluginsTable.AppendHeader(table.Row{"Path", "Execution Number", "Description"})
+	pluginsTable.AppendHeader(table.Row{"Path", "Type", "Execution Number", "Timeout", "Hook", "Description"})
 
 	for _, plugin := range plugins {
 		pluginsTable.AppendRow(
 			table.Row{
 				plugin.Path,
+				plugin.Type,
 				plugin.ExecNumber,
+				plugin.Timeout,
+				plugin.Hook,
 				text.WrapSoft(plugin.Description, session.maxTableColumnWidth),
 			},
 		)
 	}
 	pluginsTable.Render()
 }
+
+func showSteps(out io.Writer, steps []*domain.Step) {
+	stepsTable := util.NewInfoTable(out)
+	stepsTable.SetTitle("STEPS")
+	stepsTable.AppendHeader(table.Row{"Step", "Execution Number", "Timeout", "Description"})
+
+	for _, step := range steps {
+		stepsTable.AppendRow(
+			table.Row{
+				text.WrapSoft(step.Name(), session.maxTableColumnWidth),
+				step.ExecNumber,
+				step.Timeout,
+				text.WrapSoft(step.Description, session.maxTableColumnWidth),
+			},
+		)
+	}
+	stepsTable.Render()
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type pluginCommand struct {
+	cmd *cobra.Command
+}
+
+func newPluginCommand() *pluginCommand {
+	cmd := &cobra.Command{
+		Use:     "plugin",
+		Aliases: []string{"plg"},
+		Short:   "Work with plugins",
+	}
+
+	cmd.AddCommand(
+		newPluginTestCommand().cmd,
+	)
+
+	return &pluginCommand{cmd: cmd}
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/nikoksr/proji/internal/util"
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/pelletier/go-toml"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type pluginTestCommand struct {
+	cmd *cobra.Command
+}
+
+func newPluginTestCommand() *pluginTestCommand {
+	var varsFile, pluginType, timeout string
+	options := &domain.PluginTestOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "test PLUGIN",
+		Short: "Run a plugin in a temporary project and report what it did",
+		Long: `Run a plugin in a temporary project and report what it did.
+
+The plugin runs inside a temporary project folder with a fake project context. Afterwards proji reports the plugin's
+output, the files it created, changed or removed, the commands it tried to run and the variables it set. Commands that a
+lua plugin starts with os.execute or io.popen are only recorded, unless --run-commands is passed; io.popen fails for
+commands that aren't run. Commands that an executable plugin starts can't be recorded, so they aren't listed.
+
+PLUGIN is either the path of a plugin file or a path relative to proji's plugins folder.`,
+		Aliases: []string{"t"},
+		Example: `  proji plugin test git-init.lua
+  proji plugin test ./detect-go-version.sh --phase pre --vars vars.toml`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			plugin, err := newTestPlugin(args[0], pluginType, timeout)
+			if err != nil {
+				return err
+			}
+			if varsFile != "" {
+				options.Variables, err = loadVariables(varsFile)
+				if err != nil {
+					return errors.Wrap(err, "failed to load variables")
+				}
+			}
+
+			// Cancel the plugin if the user interrupts proji
+			ctx, cancel := newInterruptContext()
+			defer cancel()
+
+			report, err := session.projectService.TestPlugin(ctx, session.config.BasePath, plugin, options)
+			if err != nil {
+				return errors.Wrap(err, "failed to test plugin")
+			}
+			showPluginTestReport(os.Stdout, plugin, report, options)
+			if report.Err != nil {
+				return report.Err
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&varsFile, "vars", "", "TOML file with variables that are passed to the plugin")
+	cmd.Flags().StringVar(&options.Phase, "phase", "post", "phase to run the plugin in; pre, post or the name of a hook")
+	cmd.Flags().StringVar(&options.ProjectName, "name", "", "name of the temporary project (default \"example\")")
+	cmd.Flags().StringVar(&pluginType, "type", "", "type of the plugin; lua or exec (default based on the file extension)")
+	cmd.Flags().StringVar(&timeout, "timeout", "", "maximum time the plugin may run (default from proji's config)")
+	cmd.Flags().BoolVar(&options.RunCommands, "run-commands", false, "run the commands a lua plugin starts instead of only recording them")
+	cmd.Flags().BoolVar(&options.KeepProject, "keep", false, "keep the temporary project folder")
+
+	_ = cmd.MarkFlagFilename("vars", "toml")
+
+	return &pluginTestCommand{cmd: cmd}
+}
+
+// newTestPlugin returns a plugin for the given path. Paths of existing files are made absolute, all other paths are
+// thought to be relative to proji's plugins folder.
+func newTestPlugin(path, pluginType, timeout string) (*domain.Plugin, error) {
+	if util.DoesPathExist(path) {
+		var err error
+		path, err = filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+	} else if !util.DoesPathExist(filepath.Join(session.config.BasePath, "plugins", path)) {
+		return nil, fmt.Errorf("plugin %s not found", path)
+	}
+	return &domain.Plugin{Path: path, Type: pluginType, Timeout: timeout}, nil
+}
+
+// loadVariables loads plugin variables from a TOML file. All values are converted to strings.
+func loadVariables(path string) (map[string]string, error) {
+	tree, err := toml.LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return toStringVariables(tree.ToMap())
+}
+
+// toStringVariables converts the values of the given TOML variables to strings. Tables aren't supported.
+func toStringVariables(values map[string]interface{}) (map[string]string, error) {
+	variables := make(map[string]string, len(values))
+	for key, value := range values {
+		if _, ok := value.(map[string]interface{}); ok {
+			return nil, fmt.Errorf("variable %s is a table, only plain values are supported", key)
+		}
+		variables[key] = fmt.Sprint(value)
+	}
+	return variables, nil
+}
+
+func showPluginTestReport(out io.Writer, plugin *domain.Plugin, report *domain.PluginTestReport, options *domain.PluginTestOptions) {
+	result := "ok"
+	if report.Err != nil {
+		result = report.Err.Error()
+	}
+	projectPath := report.ProjectPath
+	if !options.KeepProject {
+		projectPath += " (removed)"
+	}
+
+	fmt.Fprintf(out, "\nPlugin:   %s\n", plugin.Path)
+	fmt.Fprintf(out, "Phase:    %s\n", report.Phase)
+	fmt.Fprintf(out, "Project:  %s\n", projectPath)
+	fmt.Fprintf(out, "Duration: %s\n", report.Duration.Round(time.Millisecond))
+	fmt.Fprintf(out, "Result:   %s\n", result)
+	if report.LogPath != "" {
+		fmt.Fprintf(out, "Log:      %s\n", report.LogPath)
+	}
+
+	output := strings.TrimRight(report.Output, "\n")
+	if output != "" {
+		fmt.Fprintf(out, "\nOutput:\n%s\n", output)
+	}
+	fmt.Fprintln(out)
+
+	filesTable := util.NewInfoTable(out)
+	filesTable.SetTitle("FILES")
+	filesTable.AppendHeader(table.Row{"Change", "Path"})
+	for _, path := range report.CreatedFiles {
+		filesTable.AppendRow(table.Row{"created", path})
+	}
+	for _, path := range report.ChangedFiles {
+		filesTable.AppendRow(table.Row{"changed", path})
+	}
+	for _, path := range report.RemovedFiles {
+		filesTable.AppendRow(table.Row{"removed", path})
+	}
+	filesTable.Render()
+
+	commandStatus := "recorded"
+	if options.RunCommands {
+		commandStatus = "run"
+	}
+	commandsTable := util.NewInfoTable(out)
+	commandsTable.SetTitle("COMMANDS")
+	commandsTable.AppendHeader(table.Row{"Command", "Status"})
+	for _, command := range report.Commands {
+		commandsTable.AppendRow(table.Row{text.WrapSoft(command, session.maxTableColumnWidth), commandStatus})
+	}
+	commandsTable.Render()
+
+	keys := make([]string, 0, len(report.Variables))
+	for key := range report.Variables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	variablesTable := util.NewInfoTable(out)
+	variablesTable.SetTitle("VARIABLES")
+	variablesTable.AppendHeader(table.Row{"Name", "Value"})
+	for _, key := range keys {
+		variablesTable.AppendRow(table.Row{key, report.Variables[key]})
+	}
+	variablesTable.Render()
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/nikoksr/proji/internal/message"
+	"github.com/nikoksr/proji/internal/statuswriter"
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type projectApplyCommand struct {
+	cmd *cobra.Command
+}
+
+func newProjectApplyCommand() *projectApplyCommand {
+	var dryRun, keepOnFailure bool
+
+	cmd := &cobra.Command{
+		Use:   "apply LABEL PATH",
+		Short: "Apply another package to an existing project",
+		Long: `Apply another package to an existing project.
+
+The templates of the package are added to the project and its plugins run, just like during the creation of a project.
+Files that already exist are never touched; only missing files are created. The package is recorded as applied to the
+project together with the files that it created, which are also added to the project's manifest. The name rules of the
+package don't apply, since the project already has a name. Updates of the project only consider the package it was
+created with, but keep the files of applied packages.`,
+		Example: `  proji apply docker ~/projects/my-service
+  proji apply ci . --dry-run`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			label := args[0]
+			path, err := filepath.Abs(args[1])
+			if err != nil {
+				return err
+			}
+
+			project, err := session.projectService.LoadProject(path)
+			if err != nil {
+				return errors.Wrap(err, "failed to load project")
+			}
+			pkg, err := session.packageService.LoadPackage(true, label)
+			if err != nil {
+				return errors.Wrap(err, "failed to load package")
+			}
+
+			options := &domain.CreateOptions{
+				KeepOnFailure: keepOnFailure,
+				Existing:      true,
+				MergeStrategy: domain.MergeStrategySkip,
+			}
+			if dryRun {
+				return showProjectPlan(os.Stdout, project.Name, project.Path, pkg, options)
+			}
+
+			// Cancel running plugins if the user interrupts proji
+			ctx, cancel := newInterruptContext()
+			defer cancel()
+
+			sw := statuswriter.New()
+			sw.Run()
+			options.NewStatusSink = func() domain.StatusSink { return sw.NewSink() }
+			err = session.projectService.ApplyPackage(ctx, session.config.BasePath, project, pkg, options)
+			sw.Wait()
+			if err != nil {
+				printPluginFailure(err)
+				return errors.Wrapf(err, "failed to apply package %s", label)
+			}
+			message.Successf("successfully applied package %s to project %s", label, path)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "show the plan for applying the package without touching disk or database")
+	cmd.Flags().BoolVar(&keepOnFailure, "keep-on-failure", false, "keep the files and folders that were created if applying the package fails")
+	return &projectApplyCommand{cmd: cmd}
+}
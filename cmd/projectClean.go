@@ -3,6 +3,7 @@ package cmd
 import (
 	"github.com/nikoksr/proji/internal/message"
 	"github.com/nikoksr/proji/internal/util"
+	"github.com/nikoksr/proji/pkg/domain"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
@@ -31,6 +32,10 @@ func cleanProjects() error {
 		return errors.Wrap(err, "failed to load all projects")
 	}
 
+	// Cancel running hooks if the user interrupts proji
+	ctx, cancel := newInterruptContext()
+	defer cancel()
+
 	for _, project := range projects {
 		// Check path
 		if util.DoesPathExist(project.Path) {
@@ -40,7 +45,9 @@ func cleanProjects() error {
 		err := session.projectService.RemoveProject(project.Path)
 		if err != nil {
 			message.Warningf("failed to remove project with path %s, %v", project.Path, err)
+			continue
 		}
+		runProjectHook(ctx, domain.HookProjectCleaned, project, nil)
 	}
 	return nil
 }
@@ -1,12 +1,23 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/nikoksr/proji/internal/statuswriter"
 	projectstore "github.com/nikoksr/proji/pkg/project/store"
 
 	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/pelletier/go-toml"
 
 	"github.com/nikoksr/proji/internal/message"
 	"github.com/pkg/errors"
@@ -20,94 +31,621 @@ type projectCreateCommand struct {
 }
 
 func newProjectCreateCommand() *projectCreateCommand {
+	var dryRun, keepOnFailure, existing, writeManifest, asJSON bool
+	var mergeStrategy, dest, from string
+	var parallel int
+
 	cmd := &cobra.Command{
-		Use:                   "create LABEL NAME [NAME...]",
-		Short:                 "Create one or more projects",
-		Aliases:               []string{"c"},
-		DisableFlagsInUseLine: true,
-		Args:                  cobra.MinimumNArgs(2),
+		Use:   "create LABEL NAME [NAME...] | create --from FILE",
+		Short: "Create one or more projects",
+		Long: `Create one or more projects.
+
+Projects are created inside of the folder given by --dest. Without it, they are created inside of the package's
+projects_root or, if the package has none, inside of the current folder. Names have to satisfy the name_rules of the
+package. Templates can use the name as {{name}} and a derived slug, e.g. my-project for "My Project", as {{slug}}.
+
+Pass . as the name to create the project inside of the current folder, or --existing to create projects inside of
+folders that already exist, e.g. freshly cloned repositories. Files that already exist are handled according to
+--merge: fail aborts the creation before anything is touched, skip keeps the existing files and overwrite replaces them.
+If such a creation fails, overwritten files are restored and the files that proji created are removed, but everything
+that plugins and steps did inside of the folder is kept.
+
+Every project records how it was created in a manifest; the package and its version, the variables, a checksum of
+every generated file and the exit status and duration of every plugin. With --manifest, the manifest is also written
+to .proji/manifest.toml inside of the project.
+
+With --parallel, several projects are created at the same time. Their progress is shown live and a summary is printed
+once all of them finished. Since the projects can't share the terminal, their lua plugins read an empty input instead
+of prompting the user.
+
+If the package has a next_steps message, it is shown after a project was created. With --json, a report of every
+project, including its next steps, is printed as JSON once all projects finished; progress is written to stderr.
+--json can't be combined with --dry-run. proji exits with a non-zero status if any project couldn't be created.
+
+With --from, the projects are read from a projects file instead of the arguments. Every [[project]] of the file has a
+package label, a name, an optional dest and optional variables that templates and plugins can use. Relative
+destinations are relative to the folder of the projects file. The variables can't override the base variables, like
+name or slug. Before any project is created, every project of the file is planned, so that unknown packages, names
+that break the name rules of their package and conflicts with existing folders or files stop the whole file. Missing
+tools and remote templates are only detected when a project is created. A summary of all projects is printed at the
+end.`,
+		Aliases: []string{"c"},
+		Example: `  proji create go my-service
+  proji create go . --merge skip
+  proji create go api worker web --parallel 3
+  proji create go my-service --dest ~/code
+  proji create go api worker --json
+  proji create --from projects.toml --parallel 4`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if from != "" {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.MinimumNArgs(2)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			label := args[0]
-			projectNames := args[1:]
+			if parallel < 1 {
+				return fmt.Errorf("parallel must be at least 1, got %d", parallel)
+			}
+			if from != "" && dest != "" {
+				return fmt.Errorf("--dest can't be used with --from; set dest in the projects file instead")
+			}
+			if dryRun && asJSON {
+				return fmt.Errorf("--json can't be used with --dry-run")
+			}
 
-			// Get current working directory
-			workingDirectory, err := os.Getwd()
-			if err != nil {
-				return errors.Wrap(err, "failed to get working directory")
+			options := &domain.CreateOptions{
+				KeepOnFailure: keepOnFailure,
+				Existing:      existing,
+				MergeStrategy: mergeStrategy,
+				WriteManifest: writeManifest,
 			}
 
-			// Load package once for all projects
-			pkg, err := session.packageService.LoadPackage(true, label)
-			if err != nil {
-				return errors.Wrap(err, "failed to load package")
+			var targets []*creationTarget
+			var err error
+			if from != "" {
+				targets, err = loadProjectsFile(from, options)
+				if err != nil {
+					return errors.Wrap(err, "failed to load projects file")
+				}
+				if !dryRun {
+					err = checkCreationTargets(targets)
+					if err != nil {
+						return errors.Wrap(err, "failed to check projects file")
+					}
+				}
+			} else {
+				targets, err = resolveCreationTargets(args[0], args[1:], dest, options)
+				if err != nil {
+					return err
+				}
+			}
+
+			// Only show what would be done
+			if dryRun {
+				for _, target := range targets {
+					err = showProjectPlan(os.Stdout, target.name, target.path, target.pkg, target.options)
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+
+			// Cancel running plugins if the user interrupts proji
+			ctx, cancel := newInterruptContext()
+			defer cancel()
+
+			if asJSON {
+				results := createProjectsInParallel(ctx, os.Stderr, targets, parallel)
+				err = showCreationReportJSON(os.Stdout, results)
+				if err != nil {
+					return err
+				}
+				return creationError(ctx, results)
+			}
+
+			// Projects of a projects file always get a summary, since a file usually lists many of them.
+			if parallel > 1 || from != "" {
+				results := createProjectsInParallel(ctx, os.Stdout, targets, parallel)
+				for _, result := range results {
+					printPluginFailure(result.err)
+				}
+				showCreationSummary(os.Stdout, results)
+				for _, result := range results {
+					if result.err == nil && result.nextSteps != "" {
+						message.Infof("next steps for project %s:", result.name)
+						printNextSteps(result.nextSteps)
+					}
+				}
+				return creationError(ctx, results)
 			}
 
-			for _, projectName := range projectNames {
-				message.Infof("creating project %s", projectName)
+			failed := 0
+			for _, target := range targets {
+				message.Infof("creating project %s", target.name)
 
-				// Try to create the project
-				projectPath := filepath.Join(workingDirectory, projectName)
-				err := createProject(projectName, projectPath, pkg)
+				// Try to create the project and show the output of every plugin in its own status line
+				sw := statuswriter.New()
+				sw.Run()
+				project, err := createProject(ctx, sw, target.name, target.path, target.pkg, target.options)
+				sw.Wait()
 				if err == nil {
-					message.Successf("successfully created project %s", projectName)
+					message.Successf("successfully created project %s", target.name)
+					printNextSteps(session.projectService.NextSteps(project))
 					continue
 				}
 
 				// Print error message
-				message.Warningf("failed to create project, %s, %s", projectName, err.Error())
+				message.Warningf("failed to create project, %s, %s", target.name, err.Error())
+				printPluginFailure(err)
 
-				// Check if error is because of a project is already associated with this path. Continue loop if so.
-				if errors.Is(err, projectstore.ErrProjectExists) {
+				// Stop creating projects if the user interrupted proji.
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+
+				// A failed creation is never stored and its files were removed again. Only offer to replace the project
+				// if another project is already associated with its path.
+				if !errors.Is(err, projectstore.ErrProjectExists) {
+					failed++
 					continue
 				}
 
 				// Continue if use doesn't want to replace the project.
 				if !util.WantTo("> Do you want to replace it?") {
+					failed++
 					continue
 				}
 
 				// Try to replace the project
-				err = replaceProject(projectName, projectPath, pkg)
+				project, err = replaceProject(ctx, target)
 				if err != nil {
-					message.Warningf("failed to replace project %s, %s", projectName, err.Error())
+					failed++
+					message.Warningf("failed to replace project %s, %s", target.name, err.Error())
+					printPluginFailure(err)
 				} else {
-					message.Successf("successfully replaced project %s", projectName)
+					message.Successf("successfully replaced project %s", target.name)
+					printNextSteps(session.projectService.NextSteps(project))
 				}
 			}
+			if failed > 0 {
+				return fmt.Errorf("failed to create %d of %d projects", failed, len(targets))
+			}
 			return nil
 		},
 	}
 
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "show the plan for the creation without touching disk or database")
+	cmd.Flags().BoolVar(&keepOnFailure, "keep-on-failure", false, "keep the files and folders of a project whose creation failed")
+	cmd.Flags().StringVar(&dest, "dest", "", "folder to create the projects in; defaults to the package's projects root or the current folder")
+	cmd.Flags().BoolVar(&existing, "existing", false, "allow projects to be created inside of existing folders")
+	cmd.Flags().StringVar(&mergeStrategy, "merge", domain.MergeStrategyFail, "how to handle existing files; fail, skip or overwrite")
+	cmd.Flags().BoolVar(&writeManifest, "manifest", false, "write the creation manifest to .proji/manifest.toml inside of the project")
+	cmd.Flags().IntVarP(&parallel, "parallel", "p", 1, "number of projects that are created at the same time")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print a report of the created projects as JSON")
+	cmd.Flags().StringVar(&from, "from", "", "TOML file that lists the projects to create")
+
+	_ = cmd.MarkFlagFilename("from", "toml")
 	return &projectCreateCommand{cmd: cmd}
 }
 
-// createProject is a small wrapper function which takes a project name, path and its associated package,
-// creates the project directory and tries to save it to storage.
-func createProject(name, path string, pkg *domain.Package) error {
+// createProject is a small wrapper function which takes a project name, path and its associated package and creates
+// the project, which saves it to storage. A project whose creation failed is not saved.
+// Plugins and hooks report their output to sinks of the given status writer. The created project is returned.
+func createProject(
+	ctx context.Context, sw *statuswriter.StatusWriter, name, path string, pkg *domain.Package, options *domain.CreateOptions,
+) (*domain.Project, error) {
+	createOptions := *options
+	createOptions.NewStatusSink = func() domain.StatusSink { return sw.NewSink() }
+
 	project := domain.NewProject(name, path, pkg)
-	err := session.projectService.CreateProject(session.config.BasePath, project)
+	err := session.projectService.CreateProject(ctx, session.config.BasePath, project, &createOptions)
+	if err != nil {
+		hookOptions := &domain.HookOptions{
+			Err:            err,
+			NewStatusSink:  createOptions.NewStatusSink,
+			NonInteractive: createOptions.NonInteractive,
+		}
+		hookErr := session.projectService.RunProjectHook(ctx, session.config.BasePath, domain.HookCreationFailed, project, hookOptions)
+		if hookErr != nil {
+			status := sw.NewSink()
+			status.Write(message.Swarningf("%s hook of project %s failed, %s", domain.HookCreationFailed, path, hookErr.Error()))
+			status.Close()
+		}
+		return nil, errors.Wrap(err, "create project")
+	}
+	return project, nil
+}
+
+// printNextSteps prints the rendered next steps message of a project's package, if it has one.
+func printNextSteps(nextSteps string) {
+	if nextSteps == "" {
+		return
+	}
+	fmt.Printf("\n  %s\n\n", strings.ReplaceAll(nextSteps, "\n", "\n  "))
+}
+
+// creationTarget is a project that is about to be created.
+type creationTarget struct {
+	name    string
+	path    string
+	pkg     *domain.Package
+	options *domain.CreateOptions
+}
+
+// resolveCreationTargets returns the targets for projects with the given names that are created from the package with
+// the given label. The projects are created inside of dest, the package's projects root or the working directory.
+func resolveCreationTargets(label string, names []string, dest string, options *domain.CreateOptions) ([]*creationTarget, error) {
+	workingDirectory, err := os.Getwd()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get working directory")
+	}
+
+	// Load package once for all projects
+	pkg, err := session.packageService.LoadPackage(true, label)
 	if err != nil {
-		return errors.Wrap(err, "create project")
+		return nil, errors.Wrap(err, "failed to load package")
 	}
-	err = session.projectService.StoreProject(project)
+	projectsRoot, err := resolveProjectsRoot(workingDirectory, dest, pkg)
 	if err != nil {
-		return errors.Wrap(err, "save project")
+		return nil, errors.Wrap(err, "failed to resolve projects root")
+	}
+
+	targets := make([]*creationTarget, 0, len(names))
+	for _, name := range names {
+		name, path, projectOptions := resolveProjectTarget(workingDirectory, projectsRoot, name, options)
+		targets = append(targets, &creationTarget{name: name, path: path, pkg: pkg, options: projectOptions})
+	}
+	return targets, nil
+}
+
+// projectsFile lists projects that are created in one run with create --from.
+type projectsFile struct {
+	Projects []*projectsFileEntry `toml:"project"`
+}
+
+// projectsFileEntry is a single project of a projects file.
+type projectsFileEntry struct {
+	Package   string                 `toml:"package"`
+	Name      string                 `toml:"name"`
+	Dest      string                 `toml:"dest"`
+	Variables map[string]interface{} `toml:"variables"`
+}
+
+// loadProjectsFile loads the projects file at the given path and returns a target for each of its projects. Every
+// package is loaded once. The variables of a project are added to a copy of the given options; the base variables, like
+// name or slug, can't be set. An error is returned if any project of the file is invalid, so that nothing is created
+// from a broken file.
+func loadProjectsFile(path string, options *domain.CreateOptions) ([]*creationTarget, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := toml.LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file := &projectsFile{}
+	err = tree.Unmarshal(file)
+	if err != nil {
+		return nil, err
+	}
+	if len(file.Projects) == 0 {
+		return nil, fmt.Errorf("%s lists no projects", path)
+	}
+
+	// Relative destinations and the name . refer to the folder of the projects file.
+	baseDirectory := filepath.Dir(path)
+	packages := make(map[string]*domain.Package)
+	paths := make(map[string]bool)
+	targets := make([]*creationTarget, 0, len(file.Projects))
+	for i, entry := range file.Projects {
+		target, err := newProjectsFileTarget(baseDirectory, entry, packages, options)
+		if err != nil {
+			return nil, errors.Wrapf(err, "project %d", i+1)
+		}
+		if paths[target.path] {
+			return nil, fmt.Errorf("project %d: %s is listed more than once", i+1, target.path)
+		}
+		paths[target.path] = true
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// newProjectsFileTarget returns the target for a single project of a projects file. Packages are loaded once and
+// cached in the given map.
+func newProjectsFileTarget(
+	baseDirectory string, entry *projectsFileEntry, packages map[string]*domain.Package, options *domain.CreateOptions,
+) (*creationTarget, error) {
+	if entry.Package == "" {
+		return nil, fmt.Errorf("package is missing")
+	}
+	if entry.Name == "" {
+		return nil, fmt.Errorf("name is missing")
+	}
+	pkg, ok := packages[entry.Package]
+	if !ok {
+		var err error
+		pkg, err = session.packageService.LoadPackage(true, entry.Package)
+		if err != nil {
+			return nil, errors.Wrapf(err, "load package %s", entry.Package)
+		}
+		packages[entry.Package] = pkg
+	}
+	variables, err := toStringVariables(entry.Variables)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range domain.BaseVariables {
+		if _, ok := variables[name]; ok {
+			return nil, fmt.Errorf("variable %s is reserved", name)
+		}
+	}
+
+	projectsRoot, err := resolveProjectsRoot(baseDirectory, entry.Dest, pkg)
+	if err != nil {
+		return nil, errors.Wrap(err, "resolve projects root")
+	}
+	name, path, projectOptions := resolveProjectTarget(baseDirectory, projectsRoot, entry.Name, options)
+	entryOptions := *projectOptions
+	entryOptions.Variables = variables
+	return &creationTarget{name: name, path: path, pkg: pkg, options: &entryOptions}, nil
+}
+
+// checkCreationTargets plans the creation of every target, so that names that break the name rules of their package
+// and conflicts with existing folders and files are reported before any project is created. Conflicts between the
+// templates of a package don't stop the creation and are reported while the project is created.
+func checkCreationTargets(targets []*creationTarget) error {
+	for _, target := range targets {
+		project := domain.NewProject(target.name, target.path, target.pkg)
+		plan, err := session.projectService.PlanProject(session.config.BasePath, project, target.options)
+		if err != nil {
+			return errors.Wrapf(err, "project %s", target.name)
+		}
+		for _, operation := range plan.Conflicts() {
+			path := filepath.Join(target.path, operation.Destination)
+			if _, err := os.Stat(path); err == nil {
+				return fmt.Errorf("project %s: %s: %s", target.name, path, operation.Conflict)
+			}
+		}
 	}
 	return nil
 }
 
-// replaceProject should usually be executed after a attempt to create a new project failed with an ErrProjectExists.
-// It will remove the given project from storage and save the new one, effectively replacing everything that's
-// associated with the given project path.
-func replaceProject(name, path string, pkg *domain.Package) error {
-	err := session.projectService.RemoveProject(path)
+// creationResult holds the outcome of the creation of a single project.
+type creationResult struct {
+	name      string
+	path      string
+	label     string
+	duration  time.Duration
+	nextSteps string
+	err       error
+}
+
+// createProjectsInParallel creates the given projects with at most parallel creations running at the same time. Every
+// project gets its own status line, followed by the status lines of its plugins. Projects that didn't start before the
+// context was canceled fail with the context's error. The progress is written to the given output.
+func createProjectsInParallel(
+	ctx context.Context, progressOutput io.Writer, targets []*creationTarget, parallel int,
+) []*creationResult {
+	sw := statuswriter.New()
+	sw.Writer.SetOutputWriter(progressOutput)
+	sw.Run()
+
+	results := make([]*creationResult, len(targets))
+	slots := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		target := target
+		result := &creationResult{name: target.name, path: target.path, label: target.pkg.Label}
+		results[i] = result
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			if ctx.Err() != nil {
+				result.err = ctx.Err()
+				return
+			}
+
+			status := sw.NewSink()
+			defer status.Close()
+			status.Write(message.Sinfof("creating project %s", result.name))
+
+			started := time.Now()
+			// Plugins of projects that are created at the same time can't share the user's input.
+			options := *target.options
+			options.NonInteractive = parallel > 1
+			project, err := createProject(ctx, sw, target.name, target.path, target.pkg, &options)
+			result.duration = time.Since(started)
+			result.err = err
+			if err != nil {
+				status.Write(message.Swarningf("failed to create project %s", result.name))
+			} else {
+				result.nextSteps = session.projectService.NextSteps(project)
+				status.Write(message.Ssuccessf("created project %s", result.name))
+			}
+		}()
+	}
+	wg.Wait()
+	sw.Wait()
+	return results
+}
+
+// showCreationSummary prints a table with the outcome of every project that was created in parallel or from a projects
+// file.
+func showCreationSummary(out io.Writer, results []*creationResult) {
+	failed := 0
+	summaryTable := util.NewInfoTable(out)
+	summaryTable.SetTitle("SUMMARY")
+	summaryTable.AppendHeader(table.Row{"Project", "Package", "Status", "Duration", "Error"})
+	for _, result := range results {
+		status, reason := "created", ""
+		if result.err != nil {
+			failed++
+			status, reason = "failed", result.err.Error()
+		}
+		summaryTable.AppendRow(table.Row{
+			text.WrapSoft(result.path, session.maxTableColumnWidth),
+			result.label,
+			status,
+			result.duration.Round(time.Millisecond),
+			text.WrapSoft(reason, session.maxTableColumnWidth),
+		})
+	}
+	summaryTable.Render()
+
+	// Failures are reported by the error of the command.
+	if failed == 0 {
+		message.Successf("successfully created %d projects", len(results))
+	}
+}
+
+// creationError returns an error if the creation of any of the given projects failed, so that proji exits with a
+// non-zero status. If the user interrupted proji, the context's error is returned instead.
+func creationError(ctx context.Context, results []*creationResult) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	failed := 0
+	for _, result := range results {
+		if result.err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to create %d of %d projects", failed, len(results))
+	}
+	return nil
+}
+
+// creationReport is the JSON representation of the outcome of the creation of a single project.
+type creationReport struct {
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	Package   string `json:"package"`
+	Status    string `json:"status"`
+	Duration  string `json:"duration"`
+	Error     string `json:"error,omitempty"`
+	NextSteps string `json:"next_steps,omitempty"`
+}
+
+// showCreationReportJSON prints the outcome of every created project as JSON.
+func showCreationReportJSON(out io.Writer, results []*creationResult) error {
+	reports := make([]*creationReport, 0, len(results))
+	for _, result := range results {
+		report := &creationReport{
+			Name:      result.name,
+			Path:      result.path,
+			Package:   result.label,
+			Status:    "created",
+			Duration:  result.duration.Round(time.Millisecond).String(),
+			NextSteps: result.nextSteps,
+		}
+		if result.err != nil {
+			report.Status, report.Error = "failed", result.err.Error()
+		}
+		reports = append(reports, report)
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(reports)
+}
+
+// resolveProjectsRoot returns the folder that projects are created in. The destination given by the user takes
+// precedence over the projects root of the package. Relative paths are relative to the working directory.
+func resolveProjectsRoot(workingDirectory, dest string, pkg *domain.Package) (string, error) {
+	root := dest
+	if root == "" {
+		root = pkg.ProjectsRoot
+	}
+	if root == "" {
+		return workingDirectory, nil
+	}
+	root, err := util.ExpandPath(root)
 	if err != nil {
-		return errors.Wrap(err, "remove project")
+		return "", err
+	}
+	if !filepath.IsAbs(root) {
+		root = filepath.Join(workingDirectory, root)
+	}
+	return root, nil
+}
+
+// resolveProjectTarget returns the name and path of a project that is created from the given name argument inside of
+// the projects root. The name . targets the working directory, which always exists.
+func resolveProjectTarget(
+	workingDirectory, projectsRoot, name string, options *domain.CreateOptions,
+) (string, string, *domain.CreateOptions) {
+	if name != "." {
+		return name, filepath.Join(projectsRoot, name), options
 	}
+	projectOptions := *options
+	projectOptions.Existing = true
+	return filepath.Base(workingDirectory), workingDirectory, &projectOptions
+}
+
+// showProjectPlan prints the plan for the creation of a project. Destinations are rendered with the variables known
+// before any plugin ran.
+func showProjectPlan(out io.Writer, name, path string, pkg *domain.Package, options *domain.CreateOptions) error {
 	project := domain.NewProject(name, path, pkg)
-	err = session.projectService.StoreProject(project)
+	plan, err := session.projectService.PlanProject(session.config.BasePath, project, options)
 	if err != nil {
-		return errors.Wrap(err, "save project")
+		return errors.Wrapf(err, "failed to plan project %s", name)
+	}
+
+	// Show template sources relative to the templates folder, just like in the package config.
+	templatesPath := filepath.Join(session.config.BasePath, "templates")
+
+	planTable := util.NewInfoTable(out)
+	planTable.SetTitle(fmt.Sprintf("PLAN FOR %s", path))
+	planTable.AppendHeader(table.Row{"Operation", "Source", "Destination", "Conflict"})
+	for _, operation := range plan.Operations {
+		source := operation.Source
+		isTask := operation.Kind == domain.OperationRunPlugin || operation.Kind == domain.OperationRunStep
+		if relSource, err := filepath.Rel(templatesPath, source); err == nil && !isTask {
+			source = relSource
+		}
+		destination := operation.Destination
+		switch {
+		case isTask:
+			destination = fmt.Sprintf("(%s-run)", operation.Phase)
+		case destination == "":
+			destination = path
+		}
+		conflict := operation.Conflict
+		if conflict == "" && operation.Exists {
+			conflict = fmt.Sprintf("exists (%s)", plan.MergeStrategy)
+		}
+		planTable.AppendRow(table.Row{
+			operation.Kind,
+			text.WrapSoft(source, session.maxTableColumnWidth),
+			text.WrapSoft(destination, session.maxTableColumnWidth),
+			conflict,
+		})
+	}
+	planTable.Render()
+
+	conflicts := len(plan.Conflicts())
+	if conflicts > 0 {
+		message.Warningf("the plan for project %s has %d conflict(s)", name, conflicts)
 	}
 	return nil
 }
+
+// replaceProject should usually be executed after a attempt to create a new project failed with an ErrProjectExists.
+// It creates the project again and replaces the project that's associated with the target's path in storage, effectively
+// replacing everything that's associated with the given project path. The old project stays stored if the creation
+// fails.
+func replaceProject(ctx context.Context, target *creationTarget) (*domain.Project, error) {
+	options := *target.options
+	options.Replace = true
+	sw := statuswriter.New()
+	sw.Run()
+	project, err := createProject(ctx, sw, target.name, target.path, target.pkg, &options)
+	sw.Wait()
+	return project, err
+}
@@ -0,0 +1,418 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nikoksr/proji/internal/config"
+	"github.com/nikoksr/proji/pkg/domain"
+	projectservice "github.com/nikoksr/proji/pkg/project/service"
+	"github.com/stretchr/testify/assert"
+)
+
+// packageServiceStub serves the packages of its map and counts how often they are loaded.
+type packageServiceStub struct {
+	domain.PackageService
+	packages map[string]*domain.Package
+	loads    int
+}
+
+func (s *packageServiceStub) LoadPackage(_ bool, label string) (*domain.Package, error) {
+	s.loads++
+	pkg, ok := s.packages[label]
+	if !ok {
+		return nil, fmt.Errorf("package %s not found", label)
+	}
+	return pkg, nil
+}
+
+// projectServiceStub creates projects without touching the disk. It records how many creations ran at the same time
+// and fails the creation of the projects in its failures map.
+type projectServiceStub struct {
+	domain.ProjectService
+	failures map[string]error
+	delay    time.Duration
+
+	mu             sync.Mutex
+	active         int
+	maxActive      int
+	created        []string
+	nonInteractive bool
+}
+
+func (s *projectServiceStub) CreateProject(_ context.Context, _ string, p *domain.Project, o *domain.CreateOptions) error {
+	s.mu.Lock()
+	s.active++
+	if s.active > s.maxActive {
+		s.maxActive = s.active
+	}
+	s.nonInteractive = o.NonInteractive
+	s.mu.Unlock()
+
+	time.Sleep(s.delay)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active--
+	if err, ok := s.failures[p.Name]; ok {
+		return err
+	}
+	s.created = append(s.created, p.Name)
+	return nil
+}
+
+func (s *projectServiceStub) RunProjectHook(context.Context, string, string, *domain.Project, *domain.HookOptions) error {
+	return nil
+}
+
+func (s *projectServiceStub) NextSteps(p *domain.Project) string {
+	return "cd " + p.Name
+}
+
+// useTestSession replaces the session with one that loads packages from the given stub and returns a function that
+// restores the original session.
+func useTestSession(basePath string, packages *packageServiceStub) func() {
+	original := session
+	session = &sessionState{
+		config:         &config.Config{BasePath: basePath, Templates: &config.Templates{}},
+		packageService: packages,
+		projectService: projectservice.New(nil, &config.Templates{}, nil),
+	}
+	return func() { session = original }
+}
+
+func TestCreationError(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		results []*creationResult
+		want    string
+	}{
+		{name: "Test no projects", ctx: context.Background()},
+		{name: "Test all created", ctx: context.Background(), results: []*creationResult{{name: "a"}, {name: "b"}}},
+		{
+			name:    "Test failed project",
+			ctx:     context.Background(),
+			results: []*creationResult{{name: "a"}, {name: "b", err: errors.New("plugin failed")}},
+			want:    "failed to create 1 of 2 projects",
+		},
+		{
+			name:    "Test interrupted",
+			ctx:     canceled,
+			results: []*creationResult{{name: "a", err: context.Canceled}},
+			want:    context.Canceled.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := creationError(tt.ctx, tt.results)
+			if tt.want == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.want)
+		})
+	}
+}
+
+func TestLoadProjectsFile(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "proji-projects-file-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	goPackage := domain.NewPackage("go", "go")
+	rootedPackage := domain.NewPackage("docs", "docs")
+	rootedPackage.ProjectsRoot = filepath.Join(tempDir, "docs")
+	packages := &packageServiceStub{packages: map[string]*domain.Package{"go": goPackage, "docs": rootedPackage}}
+	defer useTestSession(tempDir, packages)()
+
+	type target struct {
+		name      string
+		path      string
+		label     string
+		existing  bool
+		variables map[string]string
+	}
+	tests := []struct {
+		name    string
+		content string
+		want    []target
+		wantErr string
+	}{
+		{
+			name: "Test valid file",
+			content: `
+[[project]]
+  package = "go"
+  name = "api"
+  dest = "services"
+  [project.variables]
+    owner = "team-a"
+    replicas = 3
+
+[[project]]
+  package = "go"
+  name = "."
+
+[[project]]
+  package = "docs"
+  name = "handbook"
+`,
+			want: []target{
+				{
+					name:      "api",
+					path:      filepath.Join(tempDir, "services", "api"),
+					label:     "go",
+					variables: map[string]string{"owner": "team-a", "replicas": "3"},
+				},
+				{name: filepath.Base(tempDir), path: tempDir, label: "go", existing: true, variables: map[string]string{}},
+				{name: "handbook", path: filepath.Join(tempDir, "docs", "handbook"), label: "docs", variables: map[string]string{}},
+			},
+		},
+		{name: "Test no projects", content: "", wantErr: "lists no projects"},
+		{name: "Test missing package", content: "[[project]]\n  name = \"api\"\n", wantErr: "project 1: package is missing"},
+		{name: "Test missing name", content: "[[project]]\n  package = \"go\"\n", wantErr: "project 1: name is missing"},
+		{
+			name:    "Test unknown package",
+			content: "[[project]]\n  package = \"rust\"\n  name = \"api\"\n",
+			wantErr: "project 1: load package rust: package rust not found",
+		},
+		{
+			name:    "Test reserved variable",
+			content: "[[project]]\n  package = \"go\"\n  name = \"api\"\n  [project.variables]\n    slug = \"other\"\n",
+			wantErr: "project 1: variable slug is reserved",
+		},
+		{
+			name:    "Test table variable",
+			content: "[[project]]\n  package = \"go\"\n  name = \"api\"\n  [project.variables.owner]\n    name = \"a\"\n",
+			wantErr: "project 1: variable owner is a table",
+		},
+		{
+			name: "Test duplicate path",
+			content: "[[project]]\n  package = \"go\"\n  name = \"api\"\n" +
+				"[[project]]\n  package = \"go\"\n  name = \"api\"\n",
+			wantErr: "project 2: " + filepath.Join(tempDir, "api") + " is listed more than once",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tempDir, "projects.toml")
+			err := ioutil.WriteFile(path, []byte(tt.content), 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			packages.loads = 0
+
+			targets, err := loadProjectsFile(path, &domain.CreateOptions{MergeStrategy: domain.MergeStrategySkip})
+			if tt.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			got := make([]target, 0, len(targets))
+			for _, creationTarget := range targets {
+				got = append(got, target{
+					name:      creationTarget.name,
+					path:      creationTarget.path,
+					label:     creationTarget.pkg.Label,
+					existing:  creationTarget.options.Existing,
+					variables: creationTarget.options.Variables,
+				})
+				assert.Equal(t, domain.MergeStrategySkip, creationTarget.options.MergeStrategy)
+			}
+			assert.Equal(t, tt.want, got)
+
+			// Every package is loaded once.
+			assert.Equal(t, 2, packages.loads)
+		})
+	}
+}
+
+func TestCheckCreationTargets(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "proji-projects-file-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+	defer useTestSession(tempDir, &packageServiceStub{})()
+
+	_ = os.Mkdir(filepath.Join(tempDir, "existing"), os.ModePerm)
+	pkg := domain.NewPackage("go", "go")
+	pkg.NameRules = &domain.NameRules{Case: domain.NameCaseKebab}
+	pkg.Templates = []*domain.Template{
+		{IsFile: true, Destination: "README.md"},
+		{IsFile: true, Destination: "README.md"},
+	}
+	newTarget := func(name string, options *domain.CreateOptions) *creationTarget {
+		return &creationTarget{name: name, path: filepath.Join(tempDir, name), pkg: pkg, options: options}
+	}
+
+	tests := []struct {
+		name    string
+		targets []*creationTarget
+		wantErr bool
+	}{
+		{name: "Test valid targets", targets: []*creationTarget{newTarget("api", &domain.CreateOptions{})}},
+		{
+			name: "Test name rules",
+			targets: []*creationTarget{
+				newTarget("api", &domain.CreateOptions{}),
+				newTarget("My_Worker", &domain.CreateOptions{}),
+			},
+			wantErr: true,
+		},
+		{name: "Test existing folder", targets: []*creationTarget{newTarget("existing", &domain.CreateOptions{})}, wantErr: true},
+		{name: "Test existing folder allowed", targets: []*creationTarget{newTarget("existing", &domain.CreateOptions{Existing: true})}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkCreationTargets(tt.targets)
+			assert.Equal(t, tt.wantErr, err != nil, "error: %v", err)
+		})
+	}
+}
+
+func TestCreateProjectsInParallel(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "proji-parallel-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+	defer useTestSession(tempDir, &packageServiceStub{})()
+
+	pkg := domain.NewPackage("go", "go")
+	newTargets := func(names ...string) []*creationTarget {
+		targets := make([]*creationTarget, 0, len(names))
+		for _, name := range names {
+			targets = append(targets, &creationTarget{
+				name: name, path: filepath.Join(tempDir, name), pkg: pkg, options: &domain.CreateOptions{},
+			})
+		}
+		return targets
+	}
+
+	tests := []struct {
+		name               string
+		targets            []*creationTarget
+		parallel           int
+		failures           map[string]error
+		wantMaxActive      int
+		wantCreated        []string
+		wantNonInteractive bool
+	}{
+		{
+			name:          "Test sequential",
+			targets:       newTargets("a", "b", "c"),
+			parallel:      1,
+			wantMaxActive: 1,
+			wantCreated:   []string{"a", "b", "c"},
+		},
+		{
+			name:               "Test concurrency limit",
+			targets:            newTargets("a", "b", "c", "d", "e", "f"),
+			parallel:           3,
+			wantMaxActive:      3,
+			wantCreated:        []string{"a", "b", "c", "d", "e", "f"},
+			wantNonInteractive: true,
+		},
+		{
+			name:               "Test failure doesn't abort others",
+			targets:            newTargets("a", "b", "c", "d"),
+			parallel:           2,
+			failures:           map[string]error{"b": errors.New("plugin failed")},
+			wantMaxActive:      2,
+			wantCreated:        []string{"a", "c", "d"},
+			wantNonInteractive: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projects := &projectServiceStub{failures: tt.failures, delay: 50 * time.Millisecond}
+			session.projectService = projects
+
+			results := createProjectsInParallel(context.Background(), ioutil.Discard, tt.targets, tt.parallel)
+
+			assert.Equal(t, tt.wantMaxActive, projects.maxActive)
+			assert.Equal(t, tt.wantNonInteractive, projects.nonInteractive)
+			sort.Strings(projects.created)
+			assert.Equal(t, tt.wantCreated, projects.created)
+
+			// Results keep the order of the targets.
+			if !assert.Len(t, results, len(tt.targets)) {
+				return
+			}
+			for i, result := range results {
+				assert.Equal(t, tt.targets[i].name, result.name)
+				assert.Equal(t, "go", result.label)
+				if err, failed := tt.failures[result.name]; failed {
+					assert.True(t, errors.Is(result.err, err), "error of project %s: %v", result.name, result.err)
+					assert.Empty(t, result.nextSteps)
+					continue
+				}
+				assert.NoError(t, result.err)
+				assert.Equal(t, "cd "+result.name, result.nextSteps)
+				assert.NotZero(t, result.duration)
+			}
+		})
+	}
+
+	t.Run("Test canceled context", func(t *testing.T) {
+		projects := &projectServiceStub{}
+		session.projectService = projects
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		results := createProjectsInParallel(ctx, ioutil.Discard, newTargets("a", "b"), 2)
+		assert.Empty(t, projects.created)
+		for _, result := range results {
+			assert.Equal(t, context.Canceled, result.err)
+		}
+	})
+}
+
+func TestShowCreationSummary(t *testing.T) {
+	defer useTestSession("", &packageServiceStub{})()
+	session.maxTableColumnWidth = 80
+
+	var out strings.Builder
+	showCreationSummary(&out, []*creationResult{
+		{name: "api", path: "/code/api", label: "go", duration: 1500 * time.Millisecond},
+		{name: "web", path: "/code/web", label: "go", duration: 20 * time.Millisecond, err: errors.New("plugin failed")},
+	})
+
+	summary := out.String()
+	assert.Contains(t, summary, "SUMMARY")
+	lines := strings.Split(summary, "\n")
+	assertRow := func(path string, columns ...string) {
+		t.Helper()
+		for _, line := range lines {
+			if strings.Contains(line, path) {
+				for _, column := range columns {
+					assert.Contains(t, line, column, "row of %s", path)
+				}
+				return
+			}
+		}
+		t.Errorf("summary has no row for %s:\n%s", path, summary)
+	}
+	assertRow("/code/api", "go", "created", "1.5s")
+	assertRow("/code/web", "go", "failed", "20ms", "plugin failed")
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type projectDiffCommand struct {
+	cmd *cobra.Command
+}
+
+func newProjectDiffCommand() *projectDiffCommand {
+	var label string
+	var latest, asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "diff PATH",
+		Short: "Show how a project differs from its package",
+		Long: `Show how a project differs from its package.
+
+The project's files are compared with the package's output that was recorded when the project was created or last
+updated. Recorded files that the project lacks are reported as missing and files with a different content as modified.
+
+With --latest, the current version of the package is rendered with the variables that were stored for the project
+instead. Files that the package produces but the project lacks are reported as missing and files that the package
+created once but no longer produces as extra. This shows what 'proji update' would change.
+
+The differences are printed as a unified diff from the package's output to the project's files, or as JSON with --json.
+proji exits with a non-zero status if the project differs from its package, so that the command can be used in CI.`,
+		Example: `  proji diff .
+  proji diff ~/projects/my-service --json
+  proji diff . --latest --package go`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := filepath.Abs(args[0])
+			if err != nil {
+				return err
+			}
+			if label != "" && !latest {
+				return fmt.Errorf("--package can only be used together with --latest")
+			}
+			var project *domain.Project
+			if latest {
+				project, err = loadProjectWithPackage(path, label)
+				if err != nil {
+					return err
+				}
+			} else {
+				project, err = session.projectService.LoadProject(path)
+				if err != nil {
+					return errors.Wrap(err, "failed to load project")
+				}
+			}
+
+			// Cancel downloads of remote templates if the user interrupts proji
+			ctx, cancel := newInterruptContext()
+			defer cancel()
+
+			report, err := session.projectService.DiffProject(
+				ctx, session.config.BasePath, project, &domain.DiffOptions{Latest: latest},
+			)
+			if err != nil {
+				return errors.Wrap(err, "failed to diff project")
+			}
+			if asJSON {
+				err = showDriftReportJSON(os.Stdout, report)
+			} else {
+				showDriftReport(os.Stdout, report)
+			}
+			if err != nil {
+				return err
+			}
+
+			if report.HasDrift() {
+				return fmt.Errorf("project %s differs from its package in %d file(s)", path, len(report.Files))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&latest, "latest", false, "compare the project with the current version of its package")
+	cmd.Flags().StringVar(&label, "package", "", "label of the package to compare the project with when using --latest (default the project's package)")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print the differences as JSON")
+	return &projectDiffCommand{cmd: cmd}
+}
+
+func showDriftReport(out io.Writer, report *domain.DriftReport) {
+	for _, file := range report.Files {
+		fmt.Fprintf(out, "%s: %s\n", file.Kind, file.Path)
+		if file.Diff == "" {
+			continue
+		}
+		fmt.Fprint(out, file.Diff)
+	}
+}
+
+func showDriftReportJSON(out io.Writer, report *domain.DriftReport) error {
+	if report.Files == nil {
+		report.Files = make([]*domain.FileDrift, 0)
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
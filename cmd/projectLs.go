@@ -1,12 +1,17 @@
 package cmd
 
 import (
+	"io"
 	"os"
+	"strings"
 
+	"github.com/nikoksr/proji/internal/message"
 	"github.com/nikoksr/proji/internal/util"
+	"github.com/nikoksr/proji/pkg/domain"
 	"github.com/pkg/errors"
 
 	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
 	"github.com/spf13/cobra"
 )
 
@@ -15,36 +20,81 @@ type projectListCommand struct {
 }
 
 func newProjectListCommand() *projectListCommand {
+	var tags []string
+
 	cmd := &cobra.Command{
-		Use:                   "ls",
-		Short:                 "List projects",
-		Aliases:               []string{"l"},
-		DisableFlagsInUseLine: true,
-		Args:                  cobra.ExactArgs(0),
+		Use:     "ls",
+		Short:   "List projects",
+		Aliases: []string{"l"},
+		Example: `  proji ls
+  proji ls --tag client:acme --tag status:active`,
+		Args: cobra.ExactArgs(0),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return listProjects()
+			return listProjects(os.Stdout, tags)
 		},
 	}
+
+	cmd.Flags().StringSliceVarP(&tags, "tag", "t", nil, "list only projects that have all of the given tags")
 	return &projectListCommand{cmd: cmd}
 }
 
-func listProjects() error {
+func listProjects(out io.Writer, tags []string) error {
 	projects, err := session.projectService.LoadProjectList()
 	if err != nil {
 		return errors.Wrap(err, "failed to load all projects")
 	}
 
-	projectsTable := util.NewInfoTable(os.Stdout)
-	projectsTable.AppendHeader(table.Row{"Name", "Install Path", "Package"})
+	projectsTable := util.NewInfoTable(out)
+	projectsTable.AppendHeader(table.Row{"Name", "Install Path", "Package", "Tags", "Description", "Notes"})
 
+	listed := 0
 	for _, project := range projects {
+		if !hasAllTags(project, tags) {
+			continue
+		}
+		listed++
+		// Projects that were added manually or whose package was removed have no package. Packages that were applied
+		// to the project later are listed after its own package.
+		var packageNames []string
+		if project.Package != nil {
+			packageNames = append(packageNames, project.Package.Name)
+		}
+		for _, pkg := range project.AppliedPackages {
+			packageNames = append(packageNames, pkg.Name)
+		}
 		projectsTable.AppendRow(table.Row{
 			project.Name,
 			project.Path,
-			project.Package.Name,
+			strings.Join(packageNames, ", "),
+			strings.Join(project.Tags, ", "),
+			wrapText(project.Description),
+			wrapText(project.Notes),
 		})
 	}
 
+	if listed == 0 && len(tags) > 0 {
+		message.Infof("no projects with tags %s", strings.Join(tags, ", "))
+		return nil
+	}
 	projectsTable.Render()
 	return nil
 }
+
+// wrapText wraps every line of the given text at the maximum table column width without breaking words.
+func wrapText(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = text.WrapSoft(line, session.maxTableColumnWidth)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// hasAllTags reports whether the given project has all of the given tags.
+func hasAllTags(project *domain.Project, tags []string) bool {
+	for _, tag := range tags {
+		if !project.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
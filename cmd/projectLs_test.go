@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListProjects(t *testing.T) {
+	shop := domain.NewProject("shop", "/code/shop", nil)
+	shop.Tags = domain.StringList{"client:acme", "status:active"}
+	blog := domain.NewProject("blog", "/code/blog", nil)
+	blog.Tags = domain.StringList{"client:acme", "archived"}
+	tools := domain.NewProject("tools", "/code/tools", nil)
+
+	tests := []struct {
+		name     string
+		tags     []string
+		want     []string
+		wantNone bool
+	}{
+		{name: "Test all projects", want: []string{"/code/blog", "/code/shop", "/code/tools"}},
+		{name: "Test one tag", tags: []string{"client:acme"}, want: []string{"/code/blog", "/code/shop"}},
+		{name: "Test all tags", tags: []string{"client:acme", "archived"}, want: []string{"/code/blog"}},
+		{name: "Test no matching project", tags: []string{"client:acme", "status:paused"}, wantNone: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, restore := useTestProjects(shop, blog, tools)
+			defer restore()
+
+			var out strings.Builder
+			if !assert.NoError(t, listProjects(&out, tt.tags)) {
+				return
+			}
+			if tt.wantNone {
+				assert.Empty(t, out.String())
+				return
+			}
+			for _, project := range []*domain.Project{shop, blog, tools} {
+				listed := false
+				for _, path := range tt.want {
+					listed = listed || path == project.Path
+				}
+				if listed {
+					assert.Contains(t, out.String(), project.Path)
+				} else {
+					assert.NotContains(t, out.String(), project.Path)
+				}
+			}
+		})
+	}
+}
@@ -46,6 +46,10 @@ func newProjectRemoveCommand() *projectRemoveCommand {
 				}
 			}
 
+			// Cancel running hooks if the user interrupts proji
+			ctx, cancel := newInterruptContext()
+			defer cancel()
+
 			// Remove the projects
 			for _, project := range projects {
 				// Ask for confirmation if force flag was not passed
@@ -62,6 +66,7 @@ func newProjectRemoveCommand() *projectRemoveCommand {
 					continue
 				}
 				message.Successf("successfully removed project %s", project.Path)
+				runProjectHook(ctx, domain.HookProjectRemoved, project, nil)
 			}
 			return nil
 		},
@@ -15,7 +15,12 @@ func newProjectSetCommand() *projectSetCommand {
 		Aliases: []string{"s"},
 	}
 
-	cmd.AddCommand(newProjectSetPathCommand().cmd)
+	cmd.AddCommand(
+		newProjectSetDescriptionCommand().cmd,
+		newProjectSetNoteCommand().cmd,
+		newProjectSetPathCommand().cmd,
+		newProjectSetTagCommand().cmd,
+	)
 
 	return &projectSetCommand{cmd: cmd}
 }
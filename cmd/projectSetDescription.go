@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/nikoksr/proji/internal/message"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type projectSetDescription struct {
+	cmd *cobra.Command
+}
+
+func newProjectSetDescriptionCommand() *projectSetDescription {
+	cmd := &cobra.Command{
+		Use:                   "desc PATH DESCRIPTION",
+		Short:                 "Set the description of a project",
+		Long:                  "Set the description of a project. An empty description removes it.",
+		Aliases:               []string{"d", "description"},
+		DisableFlagsInUseLine: true,
+		Example:               `  proji set desc ~/projects/shop "Online shop of ACME"`,
+		Args:                  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := filepath.Abs(args[0])
+			if err != nil {
+				return err
+			}
+			project, err := session.projectService.LoadProject(path)
+			if err != nil {
+				return errors.Wrap(err, "failed to load project")
+			}
+
+			project.Description = args[1]
+			err = session.projectService.UpdateProjectDetails(project)
+			if err != nil {
+				return errors.Wrap(err, "failed setting project description")
+			}
+			message.Successf("successfully set description of project at %s", path)
+			return nil
+		},
+	}
+	return &projectSetDescription{cmd: cmd}
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/nikoksr/proji/internal/message"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type projectSetNote struct {
+	cmd *cobra.Command
+}
+
+func newProjectSetNoteCommand() *projectSetNote {
+	var appendNote bool
+
+	cmd := &cobra.Command{
+		Use:   "note PATH NOTE",
+		Short: "Set the notes of a project",
+		Long: `Set the free-form notes of a project, e.g. why it's on hold. The notes replace the previous notes of the project,
+unless --append is given. An empty note removes them.`,
+		Aliases: []string{"n", "notes"},
+		Example: `  proji set note ~/projects/shop "On hold until the new API is released"
+  proji set note ~/projects/shop --append "Ask Kim about the staging credentials"`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := filepath.Abs(args[0])
+			if err != nil {
+				return err
+			}
+			project, err := session.projectService.LoadProject(path)
+			if err != nil {
+				return errors.Wrap(err, "failed to load project")
+			}
+
+			if appendNote && project.Notes != "" {
+				project.Notes += "\n" + args[1]
+			} else {
+				project.Notes = args[1]
+			}
+			err = session.projectService.UpdateProjectDetails(project)
+			if err != nil {
+				return errors.Wrap(err, "failed setting project notes")
+			}
+			message.Successf("successfully set notes of project at %s", path)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&appendNote, "append", "a", false, "add the note on a new line after the previous notes")
+	return &projectSetNote{cmd: cmd}
+}
@@ -4,6 +4,7 @@ import (
 	"path/filepath"
 
 	"github.com/nikoksr/proji/internal/message"
+	"github.com/nikoksr/proji/pkg/domain"
 	"github.com/pkg/errors"
 
 	"github.com/spf13/cobra"
@@ -31,11 +32,23 @@ func newProjectSetPathCommand() *projectSetPath {
 				return err
 			}
 
+			project, err := session.projectService.LoadProject(oldPath)
+			if err != nil {
+				return errors.Wrap(err, "failed to load project")
+			}
+
 			err = session.projectService.UpdateProjectLocation(oldPath, newPath)
 			if err != nil {
 				return errors.Wrap(err, "failed setting project path")
 			}
 			message.Successf("successfully set path of project at %s to %s", oldPath, newPath)
+
+			// Cancel running hooks if the user interrupts proji
+			ctx, cancel := newInterruptContext()
+			defer cancel()
+
+			project.Path = newPath
+			runProjectHook(ctx, domain.HookProjectMoved, project, &domain.HookOptions{PreviousPath: oldPath})
 			return nil
 		},
 	}
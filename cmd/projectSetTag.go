@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/nikoksr/proji/internal/message"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type projectSetTag struct {
+	cmd *cobra.Command
+}
+
+func newProjectSetTagCommand() *projectSetTag {
+	var remove bool
+
+	cmd := &cobra.Command{
+		Use:   "tag PATH TAG...",
+		Short: "Add tags to a project",
+		Long: `Add tags to a project.
+
+Tags group projects, e.g. by client or status. They can't contain whitespace or commas. 'proji ls --tag' lists only
+the projects that have all of the given tags.`,
+		Aliases: []string{"t"},
+		Example: `  proji set tag ~/projects/shop client:acme status:active
+  proji set tag ~/projects/shop --remove status:active`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := filepath.Abs(args[0])
+			if err != nil {
+				return err
+			}
+			project, err := session.projectService.LoadProject(path)
+			if err != nil {
+				return errors.Wrap(err, "failed to load project")
+			}
+
+			if remove {
+				project.RemoveTags(args[1:]...)
+			} else {
+				project.AddTags(args[1:]...)
+			}
+			err = session.projectService.UpdateProjectDetails(project)
+			if err != nil {
+				return errors.Wrap(err, "failed setting project tags")
+			}
+			message.Successf("successfully set tags of project at %s to [%s]", path, strings.Join(project.Tags, ", "))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&remove, "remove", "r", false, "remove the given tags instead of adding them")
+	return &projectSetTag{cmd: cmd}
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/nikoksr/proji/internal/config"
+	"github.com/nikoksr/proji/pkg/domain"
+	projectservice "github.com/nikoksr/proji/pkg/project/service"
+	"github.com/stretchr/testify/assert"
+)
+
+// projectStoreStub keeps projects in memory.
+type projectStoreStub struct {
+	domain.ProjectStore
+	projects map[string]*domain.Project
+}
+
+func (s *projectStoreStub) LoadProject(path string) (*domain.Project, error) {
+	project, ok := s.projects[path]
+	if !ok {
+		return nil, fmt.Errorf("project %s not found", path)
+	}
+	loaded := *project
+	return &loaded, nil
+}
+
+func (s *projectStoreStub) LoadProjectList(...string) ([]*domain.Project, error) {
+	projects := make([]*domain.Project, 0, len(s.projects))
+	for _, project := range s.projects {
+		projects = append(projects, project)
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Path < projects[j].Path })
+	return projects, nil
+}
+
+func (s *projectStoreStub) UpdateProjectDetails(p *domain.Project) error {
+	s.projects[p.Path] = p
+	return nil
+}
+
+// useTestProjects replaces the session with one whose project service serves the given projects from memory and
+// returns the store and a function that restores the original session.
+func useTestProjects(projects ...*domain.Project) (*projectStoreStub, func()) {
+	restore := useTestSession("", &packageServiceStub{})
+	store := &projectStoreStub{projects: make(map[string]*domain.Project, len(projects))}
+	for _, project := range projects {
+		store.projects[project.Path] = project
+	}
+	session.projectService = projectservice.New(nil, &config.Templates{}, store)
+	session.maxTableColumnWidth = 80
+	return store, restore
+}
+
+func TestSetProjectDetails(t *testing.T) {
+	tests := []struct {
+		name            string
+		args            []string
+		tags            domain.StringList
+		description     string
+		notes           string
+		wantTags        domain.StringList
+		wantDescription string
+		wantNotes       string
+	}{
+		{
+			name:     "Test add tags",
+			args:     []string{"tag", "/code/shop", "client:acme", "status:active"},
+			tags:     domain.StringList{"client:acme"},
+			wantTags: domain.StringList{"client:acme", "status:active"},
+		},
+		{
+			name:     "Test remove tags",
+			args:     []string{"tag", "/code/shop", "--remove", "status:active", "client:acme"},
+			tags:     domain.StringList{"client:acme", "status:active"},
+			wantTags: domain.StringList{},
+		},
+		{
+			name:            "Test set description",
+			args:            []string{"desc", "/code/shop", "Online shop of ACME"},
+			tags:            domain.StringList{"archived"},
+			description:     "Shop",
+			wantTags:        domain.StringList{"archived"},
+			wantDescription: "Online shop of ACME",
+		},
+		{
+			name:        "Test clear description",
+			args:        []string{"desc", "/code/shop", ""},
+			description: "Shop",
+			notes:       "On hold",
+			wantNotes:   "On hold",
+		},
+		{
+			name:      "Test set note",
+			args:      []string{"note", "/code/shop", "On hold"},
+			notes:     "Old note",
+			wantNotes: "On hold",
+		},
+		{
+			name:      "Test append note",
+			args:      []string{"note", "/code/shop", "--append", "Ask Kim"},
+			notes:     "On hold",
+			wantNotes: "On hold\nAsk Kim",
+		},
+		{
+			name:            "Test clear note",
+			args:            []string{"note", "/code/shop", ""},
+			description:     "Shop",
+			notes:           "On hold",
+			wantDescription: "Shop",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := domain.NewProject("shop", "/code/shop", nil)
+			project.Tags = tt.tags
+			project.Description = tt.description
+			project.Notes = tt.notes
+			store, restore := useTestProjects(project)
+			defer restore()
+
+			cmd := newProjectSetCommand().cmd
+			cmd.SetArgs(tt.args)
+			if !assert.NoError(t, cmd.Execute()) {
+				return
+			}
+			updated := store.projects[project.Path]
+			assert.Equal(t, tt.wantTags, updated.Tags)
+			assert.Equal(t, tt.wantDescription, updated.Description)
+			assert.Equal(t, tt.wantNotes, updated.Notes)
+		})
+	}
+}
+
+func TestSetInvalidProjectTag(t *testing.T) {
+	project := domain.NewProject("shop", "/code/shop", nil)
+	store, restore := useTestProjects(project)
+	defer restore()
+
+	cmd := newProjectSetCommand().cmd
+	cmd.SetArgs([]string{"tag", "/code/shop", "client acme"})
+	cmd.SilenceUsage = true
+	err := cmd.Execute()
+	if assert.Error(t, err) {
+		assert.True(t, strings.HasPrefix(err.Error(), "failed setting project tags"), err.Error())
+	}
+	assert.Empty(t, store.projects[project.Path].Tags)
+}
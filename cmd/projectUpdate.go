@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/nikoksr/proji/internal/message"
+	"github.com/nikoksr/proji/internal/util"
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type projectUpdateCommand struct {
+	cmd *cobra.Command
+}
+
+func newProjectUpdateCommand() *projectUpdateCommand {
+	var label string
+	options := &domain.UpdateOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "update PATH",
+		Short: "Update a project with the latest version of its package",
+		Long: `Update a project with the latest version of its package.
+
+The package's templates are rendered again with the variables that were stored when the project was created. For every
+file, proji merges the changes that the package made since the project was created or last updated with the changes
+made in the project. Lines that both changed are marked with conflict markers:
+
+  <<<<<<< current
+  the project's version
+  =======
+  the package's version
+  >>>>>>> package
+
+Files that the package no longer creates are removed, unless they were changed in the project. Plugins don't run
+during an update.`,
+		Aliases: []string{"u"},
+		Example: `  proji update .
+  proji update ~/projects/my-service --package go --dry-run`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := filepath.Abs(args[0])
+			if err != nil {
+				return err
+			}
+			project, err := loadProjectWithPackage(path, label)
+			if err != nil {
+				return err
+			}
+
+			// Cancel downloads of remote templates if the user interrupts proji
+			ctx, cancel := newInterruptContext()
+			defer cancel()
+
+			report, err := session.projectService.UpdateProject(ctx, session.config.BasePath, project, options)
+			if err != nil {
+				return errors.Wrap(err, "failed to update project")
+			}
+			showUpdateReport(os.Stdout, path, report)
+
+			switch {
+			case options.DryRun:
+				message.Infof("dry run, nothing was changed")
+			case report.HasConflicts():
+				message.Warningf("updated project %s, resolve the conflicts before committing", path)
+			default:
+				message.Successf("successfully updated project %s", path)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&label, "package", "", "label of the package to update the project with (default the project's package)")
+	cmd.Flags().BoolVar(&options.DryRun, "dry-run", false, "show what the update would do without touching disk or database")
+	return &projectUpdateCommand{cmd: cmd}
+}
+
+// loadProjectWithPackage loads the project at the given path together with the latest version of its package. The
+// project only references its package, so the package is loaded again by its label. A label other than the one of the
+// project's package may be given, e.g. if the package was removed.
+func loadProjectWithPackage(path, label string) (*domain.Project, error) {
+	project, err := session.projectService.LoadProject(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load project")
+	}
+	if label == "" {
+		if project.Package == nil {
+			return nil, fmt.Errorf("the package of project %s no longer exists, pass one with --package", path)
+		}
+		label = project.Package.Label
+	}
+	project.Package, err = session.packageService.LoadPackage(true, label)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load package")
+	}
+	return project, nil
+}
+
+func showUpdateReport(out io.Writer, path string, report *domain.UpdateReport) {
+	updateTable := util.NewInfoTable(out)
+	updateTable.SetTitle(fmt.Sprintf("UPDATE OF %s", path))
+	updateTable.AppendHeader(table.Row{"File", "Action", "Reason"})
+	for _, file := range report.Files {
+		if file.Action == domain.UpdateUnchanged {
+			continue
+		}
+		updateTable.AppendRow(table.Row{text.WrapSoft(file.Path, session.maxTableColumnWidth), file.Action, file.Reason})
+	}
+	updateTable.Render()
+}
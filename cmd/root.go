@@ -1,7 +1,11 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"os/signal"
+	"strings"
 
 	"github.com/nikoksr/proji/internal/config"
 	"github.com/nikoksr/proji/internal/database"
@@ -35,6 +39,7 @@ func Execute() {
 	err := newRootCommand().cmd.Execute()
 	if err != nil {
 		message.Errorf(err, "")
+		os.Exit(1)
 	}
 }
 
@@ -59,12 +64,16 @@ func newRootCommand() *rootCommand {
 		newCompletionCommand().cmd,
 		newInitCommand().cmd,
 		newPackageCommand().cmd,
+		newPluginCommand().cmd,
 		newProjectAddCommand().cmd,
+		newProjectApplyCommand().cmd,
 		newProjectCleanCommand().cmd,
 		newProjectCreateCommand().cmd,
+		newProjectDiffCommand().cmd,
 		newProjectListCommand().cmd,
 		newProjectRemoveCommand().cmd,
 		newProjectSetCommand().cmd,
+		newProjectUpdateCommand().cmd,
 		newVersionCommand().cmd,
 	)
 	return &rootCommand{cmd: cmd}
@@ -142,11 +151,87 @@ func initServices() error {
 func createServices(db *database.Database) {
 	// Package Service
 	packageStore := packagestore.New(db.Connection)
-	session.packageService = packageservice.New(session.config.Auth, packageStore)
+	session.packageService = packageservice.New(session.config.Auth, session.config.Templates, packageStore)
 
 	// Project Service
 	projectStore := projectstore.New(db.Connection)
-	session.projectService = projectservice.New(projectStore)
+	session.projectService = projectservice.New(session.config.Plugins, session.config.Templates, projectStore)
+}
+
+// newInterruptContext returns a context that gets canceled as soon as the user interrupts proji, e.g. by pressing
+// Ctrl-C. Calling the returned cancel function stops listening for interrupts.
+func newInterruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+
+	go func() {
+		select {
+		case <-interrupts:
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(interrupts)
+	}()
+	return ctx, cancel
+}
+
+// runProjectHook runs the plugins that the package of the given project registered for the given hook and shows their
+// output. A failing hook doesn't undo the event that triggered it, so failures are only reported.
+func runProjectHook(ctx context.Context, hook string, project *domain.Project, options *domain.HookOptions) {
+	if options == nil {
+		options = &domain.HookOptions{}
+	}
+	sw := statuswriter.New()
+	sw.Run()
+	options.NewStatusSink = func() domain.StatusSink { return sw.NewSink() }
+
+	err := session.projectService.RunProjectHook(ctx, session.config.BasePath, hook, project, options)
+	sw.Wait()
+	if err != nil {
+		message.Warningf("%s hook of project %s failed, %s", hook, project.Path, err.Error())
+		printPluginFailure(err)
+	}
+}
+
+// printPluginFailure replays the output of a failed plugin or step, so that users don't have to dig through the plugin
+// log to find out what went wrong. For unmet requirements of a package, it lists how each requirement was checked.
+func printPluginFailure(err error) {
+	var pluginErr *projectservice.PluginError
+	var stepErr *projectservice.StepError
+	var requirementsErr *projectservice.RequirementsError
+	var output, logPath string
+	switch {
+	case errors.As(err, &requirementsErr):
+		printRequirementChecks(requirementsErr.Failed)
+		return
+	case errors.As(err, &pluginErr):
+		output, logPath = pluginErr.Output, pluginErr.LogPath
+	case errors.As(err, &stepErr):
+		output, logPath = stepErr.Output, stepErr.LogPath
+	default:
+		return
+	}
+	output = strings.TrimRight(output, "\n")
+	if output != "" {
+		fmt.Printf("\n%s\n\n", output)
+	}
+	if logPath != "" {
+		message.Infof("the full plugin log was written to %s", logPath)
+	}
+}
+
+// printRequirementChecks prints what is missing for every unmet requirement and how it was detected.
+func printRequirementChecks(checks []*projectservice.RequirementCheck) {
+	fmt.Println()
+	for _, check := range checks {
+		detection := ""
+		if check.Command != "" {
+			detection = fmt.Sprintf(" (detected with '%s')", check.Command)
+		}
+		fmt.Printf("  %s: %s%s\n", check.Requirement, check.Problem, detection)
+	}
+	fmt.Println()
 }
 
 func getTerminalWidth() (int, error) {
@@ -158,6 +243,12 @@ func getTerminalWidth() (int, error) {
 }
 
 func getMaxColumnWidth() int {
+	// Output that isn't written to a terminal, e.g. JSON that is piped into another program, must not be mixed with
+	// warnings.
+	if !terminal.IsTerminal(int(os.Stdout.Fd())) {
+		return 50
+	}
+
 	// Load terminal width and set max column width for dynamic rendering
 	terminalWidth, err := getTerminalWidth()
 	if err != nil {
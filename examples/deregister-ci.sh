@@ -0,0 +1,13 @@
+#!/bin/sh
+# Example of a hook plugin. It runs on the project-removed hook and tells a CI server to forget the removed project.
+# The CI server and its token are read from the environment, so the plugin does nothing where they aren't set.
+
+if [ -z "${CI_SERVER_URL}" ] || [ -z "${CI_TOKEN}" ]; then
+	echo "CI_SERVER_URL or CI_TOKEN not set, skipping deregistration of ${PROJI_PROJECT_NAME}."
+	exit 0
+fi
+
+echo "Deregistering ${PROJI_PROJECT_NAME} (${PROJI_PROJECT_PATH}) from ${CI_SERVER_URL}..."
+curl --fail --silent --show-error -X DELETE \
+	-H "Authorization: Bearer ${CI_TOKEN}" \
+	"${CI_SERVER_URL}/api/projects/${PROJI_PROJECT_NAME}"
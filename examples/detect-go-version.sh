@@ -0,0 +1,14 @@
+#!/bin/sh
+# Example of an executable plugin. It reads the project context from the PROJI_* environment variables (the same
+# context is also passed as JSON on stdin) and hands the detected go version back to proji as a variable.
+
+if ! command -v go >/dev/null 2>&1; then
+	echo "Error: go is not installed." >&2
+	exit 1
+fi
+
+echo "Detecting go version for project ${PROJI_PROJECT_NAME}..."
+version=$(go env GOVERSION 2>/dev/null | sed 's/^go//')
+
+# The last line written to stdout may be a JSON object holding the plugin's result.
+printf '{"variables": {"go_version": "%s"}}\n' "${version}"
@@ -1,36 +1,12 @@
---- Smaller wrapper function to reduce code redundancy when dealing with shell commands.
-local function execShellCommand(command, err_msg)
-	if not os.execute(command) then
-		io.stderr.write("Error: " .. err_msg)
-		os.exit(1)
-	end
-end
-
---- Check if a file or directory exists in this path
-local function exists(file)
-	local ok, err, code = os.rename(file, file)
-	if not ok then
-		if code == 13 then
-			--- Permission denied, but it exists
-			return true
-		end
-	end
-	return ok, err
-end
-
---- Check if a directory exists in this path
-local function doesDirExist(path)
-	--- "/" works on both Unix and Windows
-	return exists(path .. "/")
-end
+local std = require("proji.std")
+local execShellCommand = std.exec
 
 --- Initialize the git repository
 local function gitInit()
 	--- Check if git was already initialized
 	io.stdout:write("Checking for existing repository...\n")
-	if doesDirExist(".git") then
-		io.stderr:write("Error: failed to create git repo. Found an existing .git directory.\n")
-		os.exit(1)
+	if std.is_dir(".git") then
+		std.fail("failed to create git repo. Found an existing .git directory.")
 	end
 
 	--- Initialize git repo
@@ -42,16 +18,18 @@ end
 local function gitRemoteAdd()
 	io.stdout:write("Remote name (defaults to origin): ")
 	local remote_name = io.stdin:read()
-	if remote_name == "n" or remote_name == "" then
+	if remote_name == nil or remote_name == "n" or remote_name == "" then
 		remote_name = "origin"
 	end
 
 	--- Add an upload url
 	io.stdout:write("Remote url: ")
 	local remote_url = io.stdin:read()
+	if remote_url == nil then
+		std.fail("failed to set the git remote url. No input available, projects created with --parallel can't prompt.")
+	end
 	if remote_url == "n" or remote_url == "" then
-		io.stderr:write("Error: failed to set the git remote url. Remote url may not be empty.\n")
-		os.exit(1)
+		std.fail("failed to set the git remote url. Remote url may not be empty.")
 	end
 	if not remote_url:match(".git$") then
 		remote_url = remote_url .. ".git"
@@ -95,8 +73,7 @@ local function main()
 
 	--- Check if shell is usable
 	if not os.execute() then
-		io.stderr:write("Error: shell is not available.\n")
-		os.exit(1)
+		std.fail("shell is not available.")
 	end
 
 	--- Initialize the git repository
@@ -109,15 +86,10 @@ local function main()
 	gitAddTag()
 
 	--- Check if git remote should be added
-	local input
-	repeat
-		io.stdout:write("Add a git remote? [y/N] ")
-		input = string.lower(io.stdin:read())
-		if input == "n" or input == "" then
-			io.stdout:write("Done...\n")
-			os.exit(0)
-		end
-	until input == "y"
+	if not std.confirm("Add a git remote?", false) then
+		io.stdout:write("Done...\n")
+		os.exit(0)
+	end
 
 	--- Add a remote name; typically 'origin'
 	local remote_name = gitRemoteAdd()
@@ -1,25 +1,11 @@
---- Smaller wrapper function to reduce code redundancy when dealing with shell commands.
-local function execShellCommand(command, err_msg)
-	if not os.execute(command) then
-		io.stderr:write("Error: " .. err_msg)
-		os.exit(1)
-	end
-end
-
---- Check if os is *nix
-local function isOSUnix()
-	if package.config:sub(1, 1) == "/" then
-		return true
-	end
-	return false
-end
+local std = require("proji.std")
+local execShellCommand = std.exec
 
 --- Main wrapper function
 function main()
 	--- Check if shell is usable
 	if not os.execute() then
-		io.stderr:write("Error: shell is not available.\n")
-		os.exit(1)
+		std.fail("shell is not available.")
 	end
 
 	io.stdout:write("Initializing virtualenv...\n")
@@ -31,7 +17,7 @@ function main()
 	--- Activation command differs depending on OS
 	local err_msg = "failed to activate virtualenv."
 	local command
-	if isOSUnix() then
+	if std.is_unix() then
 		command = "source .env/bin/activate"
 	else
 		command = ".env\\Scripts\\activate"
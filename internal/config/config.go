@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/spf13/pflag"
 
@@ -34,6 +35,27 @@ type Import struct {
 	Exclude string `mapstructure:"exclude"`
 }
 
+// Plugins represents plugin related config settings.
+type Plugins struct {
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// Templates represents settings for the names of files and folders that are created from template folders.
+type Templates struct {
+	// DotPrefixes are prefixes of names that are replaced by a dot, e.g. dot_gitignore becomes .gitignore.
+	DotPrefixes []string `mapstructure:"dot_prefixes"`
+
+	// StripSuffixes are suffixes that are removed from names, e.g. main.go.tmpl becomes main.go.
+	StripSuffixes []string `mapstructure:"strip_suffixes"`
+
+	// Placeholders turns parts of names like __name__ into placeholders of the variable of the same name.
+	Placeholders bool `mapstructure:"placeholders"`
+
+	// KeepFiles are names of placeholder files that keep empty folders in version control, e.g. .gitkeep. Imports of
+	// directory structures don't turn them into templates but mark their folder as one that needs a keep file.
+	KeepFiles []string `mapstructure:"keep_files"`
+}
+
 // Config represents projis main config holding information about central resources the app uses.
 type Config struct {
 	Auth               *APIAuthentication  `mapstructure:"auth"`
@@ -41,12 +63,15 @@ type Config struct {
 	Core               *Core               `mapstructure:"core"`
 	DatabaseConnection *DatabaseConnection `mapstructure:"database"`
 	Import             *Import             `mapstructure:"import"`
+	Plugins            *Plugins            `mapstructure:"plugins"`
+	Templates          *Templates          `mapstructure:"templates"`
 	provider           *viper.Viper        `mapstructure:"-"`
 }
 
 const (
 	defaultDatabaseDriver = "sqlite3"
 	defaultDatabaseDSN    = "/db/proji.sqlite3"
+	defaultPluginTimeout  = "10m"
 )
 
 //nolint:gochecknoglobals
@@ -117,6 +142,11 @@ func (c *Config) setDefaultValues() {
 	c.provider.SetDefault("database.driver", defaultDatabaseDriver)
 	c.provider.SetDefault("database.dsn", filepath.Join(c.BasePath, defaultDatabaseDSN))
 	c.provider.SetDefault("import.exclude", `^(.git|.env|.idea|.vscode)$`)
+	c.provider.SetDefault("plugins.timeout", defaultPluginTimeout)
+	c.provider.SetDefault("templates.dot_prefixes", []string{})
+	c.provider.SetDefault("templates.strip_suffixes", []string{})
+	c.provider.SetDefault("templates.placeholders", false)
+	c.provider.SetDefault("templates.keep_files", []string{".gitkeep", ".keep"})
 }
 
 // set should run after loadFile and loadEnvironmentVariables. It sets the loaded values as the final config.
@@ -37,6 +37,7 @@ func newMainConfigFolder() *mainConfigFolder {
 		subFolders: []string{
 			"db",
 			"plugins",
+			"plugins/lib",
 			"templates",
 		},
 	}
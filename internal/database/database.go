@@ -55,8 +55,18 @@ func New(driver, connectionString string) (*Database, error) {
 	return db, nil
 }
 
+// Migrate creates or updates the tables of all models. The associations of packages with templates and plugins have
+// their own models, since they hold settings of the package's templates and plugins.
 func (db Database) Migrate() error {
-	return db.Connection.AutoMigrate(&domain.Package{}, &domain.Project{})
+	err := db.Connection.SetupJoinTable(&domain.Package{}, "Templates", &domain.PackageTemplate{})
+	if err != nil {
+		return errors.Wrap(err, "setup package templates")
+	}
+	err = db.Connection.SetupJoinTable(&domain.Package{}, "Plugins", &domain.PackagePlugin{})
+	if err != nil {
+		return errors.Wrap(err, "setup package plugins")
+	}
+	return db.Connection.AutoMigrate(&domain.Package{}, &domain.Step{}, &domain.Project{}, &domain.ProjectFile{}, &domain.Manifest{})
 }
 
 // getDialector returns a sql dialector corresponding to a given driver. The dialector holds an opened
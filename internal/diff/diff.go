@@ -0,0 +1,111 @@
+// Package diff compares and merges text line by line.
+package diff
+
+import (
+	"strings"
+)
+
+// SplitLines splits the given text into lines. Every line keeps its line break, so that joining the lines results in
+// the original text again.
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// match returns for every line of a the index of the line of b that it matches in a longest common subsequence of
+// both, or -1 if the line isn't part of it.
+func match(a, b []string) []int {
+	matches := make([]int, len(a))
+	for i := range matches {
+		matches[i] = -1
+	}
+
+	// Lines at the start and the end that both have in common don't need to be part of the expensive comparison.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		matches[prefix] = prefix
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		matches[len(a)-1-suffix] = len(b) - 1 - suffix
+		suffix++
+	}
+
+	middleA := a[prefix : len(a)-suffix]
+	middleB := b[prefix : len(b)-suffix]
+	n, m := len(middleA), len(middleB)
+	if n == 0 || m == 0 {
+		return matches
+	}
+
+	matchMiddle(middleA, middleB, prefix, prefix, matches)
+	return matches
+}
+
+// matchMiddle finds a longest common subsequence of a and b with Hirschberg's algorithm, which only needs memory
+// linear in the length of b, and records it in matches. offsetA and offsetB are the positions of a and b in the
+// compared texts.
+func matchMiddle(a, b []string, offsetA, offsetB int, matches []int) {
+	if len(a) == 0 || len(b) == 0 {
+		return
+	}
+	if len(a) == 1 {
+		for j, line := range b {
+			if line == a[0] {
+				matches[offsetA] = offsetB + j
+				return
+			}
+		}
+		return
+	}
+
+	// Split b where the longest common subsequences of both halves of a with the parts of b add up to the longest.
+	half := len(a) / 2
+	forward := lcsLengths(a[:half], b, false)
+	backward := lcsLengths(a[half:], b, true)
+	split, longest := 0, -1
+	for j := 0; j <= len(b); j++ {
+		if length := forward[j] + backward[len(b)-j]; length > longest {
+			split, longest = j, length
+		}
+	}
+	matchMiddle(a[:half], b[:split], offsetA, offsetB, matches)
+	matchMiddle(a[half:], b[split:], offsetA+half, offsetB+split, matches)
+}
+
+// lcsLengths returns the lengths of the longest common subsequences of a and every prefix of b, where the element at
+// index j belongs to the prefix of length j. If reverse is set, both are compared from their ends, so the element at
+// index j belongs to the suffix of b of length j.
+func lcsLengths(a, b []string, reverse bool) []int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for i := range a {
+		lineA := a[i]
+		if reverse {
+			lineA = a[len(a)-1-i]
+		}
+		for j := 1; j <= len(b); j++ {
+			lineB := b[j-1]
+			if reverse {
+				lineB = b[len(b)-j]
+			}
+			switch {
+			case lineA == lineB:
+				current[j] = previous[j-1] + 1
+			case previous[j] >= current[j-1]:
+				current[j] = previous[j]
+			default:
+				current[j] = current[j-1]
+			}
+		}
+		previous, current = current, previous
+	}
+	return previous
+}
@@ -0,0 +1,56 @@
+package diff
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want []int
+	}{
+		{name: "Test equal", a: "a\nb\nc\n", b: "a\nb\nc\n", want: []int{0, 1, 2}},
+		{name: "Test empty", a: "a\nb\n", b: "", want: []int{-1, -1}},
+		{name: "Test changed line", a: "a\nb\nc\n", b: "a\nB\nc\n", want: []int{0, -1, 2}},
+		{name: "Test inserted lines", a: "a\nc\n", b: "a\nb\nb\nc\n", want: []int{0, 3}},
+		{name: "Test removed lines", a: "a\nb\nc\nd\n", b: "b\nd\n", want: []int{-1, 0, -1, 1}},
+		{name: "Test moved line", a: "a\nb\nc\nd\n", b: "b\nc\nd\na\n", want: []int{-1, 0, 1, 2}},
+		{name: "Test no common lines", a: "a\nb\n", b: "c\nd\ne\n", want: []int{-1, -1}},
+		{name: "Test repeated lines", a: "x\na\nx\nb\n", b: "a\nx\nb\nx\n", want: []int{-1, 0, 1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, match(SplitLines(tt.a), SplitLines(tt.b)))
+		})
+	}
+}
+
+func TestMatchLargeInput(t *testing.T) {
+	// Without common lines at the start and the end, a quadratic comparison would need hundreds of megabytes of memory.
+	var a, b []string
+	for i := 0; i < 10000; i++ {
+		a = append(a, fmt.Sprintf("line %d\n", i))
+		if i%2 == 0 {
+			b = append(b, fmt.Sprintf("line %d\n", i))
+		}
+	}
+	a = append([]string{"first\n"}, a...)
+	b = append(b, "last\n")
+
+	matches := match(a, b)
+	if assert.Len(t, matches, len(a)) {
+		assert.Equal(t, -1, matches[0])
+		for i := 1; i < len(a); i++ {
+			if (i-1)%2 == 0 {
+				assert.Equal(t, (i-1)/2, matches[i], "match of line %d", i)
+			} else {
+				assert.Equal(t, -1, matches[i], "match of line %d", i)
+			}
+		}
+	}
+}
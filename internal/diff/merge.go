@@ -0,0 +1,93 @@
+package diff
+
+import (
+	"strings"
+)
+
+// Markers that surround a conflict in the result of a merge.
+const (
+	conflictStart     = "<<<<<<< "
+	conflictSeparator = "=======\n"
+	conflictEnd       = ">>>>>>> "
+)
+
+// MergeResult is the result of a three-way merge.
+type MergeResult struct {
+	// Text is the merged text. Conflicting changes are surrounded by conflict markers.
+	Text string
+
+	// Conflicts is the number of conflicts in the merged text.
+	Conflicts int
+}
+
+// Merge merges the changes that were made to base in ours and in theirs. Changes that only one side made are applied.
+// Changes that both sides made to the same lines are marked as a conflict, just like git does:
+//
+//	<<<<<<< oursLabel
+//	lines of ours
+//	=======
+//	lines of theirs
+//	>>>>>>> theirsLabel
+func Merge(base, ours, theirs, oursLabel, theirsLabel string) *MergeResult {
+	baseLines, ourLines, theirLines := SplitLines(base), SplitLines(ours), SplitLines(theirs)
+	ourMatches := match(baseLines, ourLines)
+	theirMatches := match(baseLines, theirLines)
+
+	var merged strings.Builder
+	result := &MergeResult{}
+	i, j, k := 0, 0, 0
+	for {
+		// Copy the lines that neither side changed.
+		stable := 0
+		for i+stable < len(baseLines) && ourMatches[i+stable] == j+stable && theirMatches[i+stable] == k+stable {
+			stable++
+		}
+		if stable > 0 {
+			merged.WriteString(strings.Join(baseLines[i:i+stable], ""))
+			i, j, k = i+stable, j+stable, k+stable
+			continue
+		}
+
+		// Find the next line of base that both sides kept. Everything up to it was changed by at least one side.
+		nextI, nextJ, nextK := len(baseLines), len(ourLines), len(theirLines)
+		for l := i; l < len(baseLines); l++ {
+			if ourMatches[l] >= 0 && theirMatches[l] >= 0 {
+				nextI, nextJ, nextK = l, ourMatches[l], theirMatches[l]
+				break
+			}
+		}
+		if nextI == i && nextJ == j && nextK == k {
+			break
+		}
+
+		baseChunk := strings.Join(baseLines[i:nextI], "")
+		ourChunk := strings.Join(ourLines[j:nextJ], "")
+		theirChunk := strings.Join(theirLines[k:nextK], "")
+		switch {
+		case ourChunk == baseChunk:
+			merged.WriteString(theirChunk)
+		case theirChunk == baseChunk, ourChunk == theirChunk:
+			merged.WriteString(ourChunk)
+		default:
+			result.Conflicts++
+			merged.WriteString(conflictStart + oursLabel + "\n")
+			merged.WriteString(withLineBreak(ourChunk))
+			merged.WriteString(conflictSeparator)
+			merged.WriteString(withLineBreak(theirChunk))
+			merged.WriteString(conflictEnd + theirsLabel + "\n")
+		}
+		i, j, k = nextI, nextJ, nextK
+	}
+
+	result.Text = merged.String()
+	return result
+}
+
+// withLineBreak makes sure that the given chunk ends with a line break, so that a conflict marker that follows it
+// starts on its own line.
+func withLineBreak(chunk string) string {
+	if chunk == "" || strings.HasSuffix(chunk, "\n") {
+		return chunk
+	}
+	return chunk + "\n"
+}
@@ -0,0 +1,77 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name          string
+		base          string
+		ours          string
+		theirs        string
+		want          string
+		wantConflicts int
+	}{
+		{
+			name:   "Test no changes",
+			base:   "a\nb\nc\n",
+			ours:   "a\nb\nc\n",
+			theirs: "a\nb\nc\n",
+			want:   "a\nb\nc\n",
+		},
+		{
+			name:   "Test only theirs changed",
+			base:   "a\nb\nc\n",
+			ours:   "a\nb\nc\n",
+			theirs: "a\nB\nc\nd\n",
+			want:   "a\nB\nc\nd\n",
+		},
+		{
+			name:   "Test both changed different lines",
+			base:   "a\nb\nc\nd\ne\n",
+			ours:   "A\nb\nc\nd\ne\n",
+			theirs: "a\nb\nc\nd\nE\n",
+			want:   "A\nb\nc\nd\nE\n",
+		},
+		{
+			name:   "Test both made the same change",
+			base:   "a\nb\nc\n",
+			ours:   "a\nx\nc\n",
+			theirs: "a\nx\nc\n",
+			want:   "a\nx\nc\n",
+		},
+		{
+			name:          "Test both changed the same line",
+			base:          "a\nb\nc\n",
+			ours:          "a\nmine\nc\n",
+			theirs:        "a\nnew\nc\n",
+			want:          "a\n<<<<<<< current\nmine\n=======\nnew\n>>>>>>> package\nc\n",
+			wantConflicts: 1,
+		},
+		{
+			name:          "Test conflict without trailing line break",
+			base:          "a\nb",
+			ours:          "a\nmine",
+			theirs:        "a\nnew",
+			want:          "a\n<<<<<<< current\nmine\n=======\nnew\n>>>>>>> package\n",
+			wantConflicts: 1,
+		},
+		{
+			name:   "Test empty base",
+			base:   "",
+			ours:   "",
+			theirs: "a\n",
+			want:   "a\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Merge(tt.base, tt.ours, tt.theirs, "current", "package")
+			assert.Equal(t, tt.want, got.Text)
+			assert.Equal(t, tt.wantConflicts, got.Conflicts)
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines that a unified diff shows around every change.
+const DefaultContext = 3
+
+// edit is a single line of an edit script; an unchanged, removed or added line.
+type edit struct {
+	kind byte
+	line string
+}
+
+// edits returns the edit script that turns a into b.
+func edits(a, b []string) []edit {
+	matches := match(a, b)
+	script := make([]edit, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && matches[i] == -1:
+			script = append(script, edit{kind: '-', line: a[i]})
+			i++
+		case i < len(a) && matches[i] == j:
+			script = append(script, edit{kind: ' ', line: a[i]})
+			i++
+			j++
+		default:
+			script = append(script, edit{kind: '+', line: b[j]})
+			j++
+		}
+	}
+	return script
+}
+
+// Unified returns the unified diff between the texts from and to, with the given number of unchanged context lines
+// around every change. It returns an empty string if both texts are equal.
+func Unified(fromName, toName, from, to string, context int) string {
+	script := edits(SplitLines(from), SplitLines(to))
+
+	// fromLines[i] and toLines[i] hold the number of lines of from and to that come before the i-th edit.
+	fromLines := make([]int, len(script)+1)
+	toLines := make([]int, len(script)+1)
+	for i, e := range script {
+		fromLines[i+1], toLines[i+1] = fromLines[i], toLines[i]
+		if e.kind != '+' {
+			fromLines[i+1]++
+		}
+		if e.kind != '-' {
+			toLines[i+1]++
+		}
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(script); {
+		if script[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		// Changes that are close to each other share a hunk.
+		last := i
+		for {
+			next := last + 1
+			for next < len(script) && script[next].kind == ' ' {
+				next++
+			}
+			if next >= len(script) || next-last-1 > 2*context {
+				break
+			}
+			last = next
+		}
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := last + 1 + context
+		if end > len(script) {
+			end = len(script)
+		}
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n",
+			hunkRange(fromLines[start], fromLines[end]-fromLines[start]),
+			hunkRange(toLines[start], toLines[end]-toLines[start]),
+		)
+		for _, e := range script[start:end] {
+			out.WriteByte(e.kind)
+			out.WriteString(e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+	return out.String()
+}
+
+// hunkRange formats the range of a hunk. Lines are counted from one; empty ranges point at the line before them.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
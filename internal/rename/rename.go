@@ -0,0 +1,59 @@
+// Package rename applies the rename rules of the template settings to paths of template files and folders.
+package rename
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/nikoksr/proji/internal/config"
+)
+
+// namePlaceholderPattern matches placeholders in names of template files and folders, like __name__ or
+// __package_label__.
+var namePlaceholderPattern = regexp.MustCompile(`__([A-Za-z0-9]+(?:_[A-Za-z0-9]+)*)__`)
+
+// TemplatePath applies the rename rules of the given settings to every name of the given relative path of a
+// template file or folder. Names that start with a dot prefix start with a dot instead, suffixes that should be
+// stripped are removed and, if enabled, placeholders like __name__ are turned into {{ name }}. Only placeholders of the
+// given variables are turned into placeholders; all others, like __init__, are kept. The path is returned unchanged if
+// the settings are nil.
+func TemplatePath(path string, settings *config.Templates, variables []string) string {
+	if settings == nil || path == "" || path == "." {
+		return path
+	}
+	names := strings.Split(filepath.ToSlash(path), "/")
+	for i, name := range names {
+		names[i] = renameTemplateName(name, settings, variables)
+	}
+	return filepath.FromSlash(strings.Join(names, "/"))
+}
+
+// renameTemplateName applies the rename rules to a single name of a template path. A rule never produces an empty
+// name; a file named .tmpl stays .tmpl.
+func renameTemplateName(name string, settings *config.Templates, variables []string) string {
+	for _, prefix := range settings.DotPrefixes {
+		if prefix != "" && strings.HasPrefix(name, prefix) && len(name) > len(prefix) {
+			name = "." + strings.TrimPrefix(name, prefix)
+			break
+		}
+	}
+	for _, suffix := range settings.StripSuffixes {
+		if suffix != "" && strings.HasSuffix(name, suffix) && len(name) > len(suffix) {
+			name = strings.TrimSuffix(name, suffix)
+			break
+		}
+	}
+	if !settings.Placeholders {
+		return name
+	}
+	return namePlaceholderPattern.ReplaceAllStringFunc(name, func(placeholder string) string {
+		variable := namePlaceholderPattern.FindStringSubmatch(placeholder)[1]
+		for _, known := range variables {
+			if variable == known {
+				return "{{ " + variable + " }}"
+			}
+		}
+		return placeholder
+	})
+}
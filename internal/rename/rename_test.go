@@ -0,0 +1,47 @@
+package rename
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/nikoksr/proji/internal/config"
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplatePath(t *testing.T) {
+	settings := &config.Templates{
+		DotPrefixes:   []string{"_dot_", "dot_"},
+		StripSuffixes: []string{".tmpl"},
+		Placeholders:  true,
+	}
+	tests := []struct {
+		name     string
+		path     string
+		settings *config.Templates
+		want     string
+	}{
+		{name: "Test dot prefix", path: "dot_gitignore", settings: settings, want: ".gitignore"},
+		{name: "Test underscore dot prefix", path: "_dot_github/workflows/ci.yml", settings: settings, want: ".github/workflows/ci.yml"},
+		{name: "Test suffix", path: "cmd/main.go.tmpl", settings: settings, want: "cmd/main.go"},
+		{name: "Test prefix and suffix", path: "dot_env.tmpl", settings: settings, want: ".env"},
+		{name: "Test name only prefix", path: "dot_", settings: settings, want: "dot_"},
+		{name: "Test name only suffix", path: ".tmpl", settings: settings, want: ".tmpl"},
+		{name: "Test placeholder", path: "cmd/__name__/main.go", settings: settings, want: "cmd/{{ name }}/main.go"},
+		{name: "Test placeholder in name", path: "__package_label__-__slug__.md", settings: settings, want: "{{ package_label }}-{{ slug }}.md"},
+		{name: "Test unknown placeholder", path: "src/__init__.py", settings: settings, want: "src/__init__.py"},
+		{
+			name:     "Test disabled placeholders",
+			path:     "dot_config/__name__",
+			settings: &config.Templates{DotPrefixes: []string{"dot_"}},
+			want:     ".config/__name__",
+		},
+		{name: "Test without settings", path: "dot_gitignore.tmpl", want: "dot_gitignore.tmpl"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TemplatePath(filepath.FromSlash(tt.path), tt.settings, domain.BaseVariables)
+			assert.Equal(t, filepath.FromSlash(tt.want), got)
+		})
+	}
+}
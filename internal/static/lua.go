@@ -0,0 +1,96 @@
+package static
+
+// LuaStandardLibrary is the source of the lua module 'proji.std'. It bundles helpers that are needed by most plugins.
+// Plugins load it with 'local std = require("proji.std")'.
+const LuaStandardLibrary = `--- proji.std - helpers shared by proji's lua plugins
+local std = {}
+
+--- Write an error message to stderr and stop the plugin with a non-zero exit status.
+function std.fail(err_msg)
+	io.stderr:write("Error: " .. err_msg .. "\n")
+	os.exit(1)
+end
+
+--- Run a shell command and report whether it succeeded.
+function std.run(command)
+	local result = os.execute(command)
+	return result == true or result == 0
+end
+
+--- Run a shell command and stop the plugin with the given error message if it fails.
+function std.exec(command, err_msg)
+	if not std.run(command) then
+		std.fail(err_msg or ("command failed: " .. command))
+	end
+end
+
+--- std.exists(path), std.is_dir(path) and std.is_file(path) are provided by proji itself.
+
+--- Check if the operating system is *nix.
+function std.is_unix()
+	return package.config:sub(1, 1) == "/"
+end
+
+--- Read the whole content of a file. Returns nil and an error message if the file can't be read.
+function std.read_file(path)
+	local file, err = io.open(path, "r")
+	if not file then
+		return nil, err
+	end
+	local content = file:read("*a")
+	file:close()
+	return content
+end
+
+local function write(path, content, mode)
+	local file, err = io.open(path, mode)
+	if not file then
+		return false, err
+	end
+	file:write(content)
+	file:close()
+	return true
+end
+
+--- Write content to a file, replacing its previous content.
+function std.write_file(path, content)
+	return write(path, content, "w")
+end
+
+--- Append content to a file.
+function std.append_file(path, content)
+	return write(path, content, "a")
+end
+
+--- Ask the user a question and return the answer. Returns the default if the answer is empty.
+function std.prompt(question, default)
+	if default and default ~= "" then
+		io.stdout:write(question .. " [" .. default .. "]: ")
+	else
+		io.stdout:write(question .. ": ")
+	end
+	local answer = io.stdin:read() or ""
+	if answer == "" then
+		return default
+	end
+	return answer
+end
+
+--- Ask the user a yes/no question. Returns the default if the answer is empty.
+function std.confirm(question, default)
+	local choices = default and "[Y/n]" or "[y/N]"
+	while true do
+		io.stdout:write(question .. " " .. choices .. " ")
+		local answer = string.lower(io.stdin:read() or "")
+		if answer == "" then
+			return default == true
+		elseif answer == "y" or answer == "yes" then
+			return true
+		elseif answer == "n" or answer == "no" then
+			return false
+		end
+	end
+end
+
+return std
+`
@@ -12,5 +12,8 @@ description = ""
 
 [[plugin]]
   path = ""
+  type = ""
   exec_number = 1
+  timeout = ""
+  hook = ""
   description = ""`
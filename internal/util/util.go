@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -49,6 +50,19 @@ func SkipNetworkBasedTests(t *testing.T) {
 	}
 }
 
+// ExpandPath replaces environment variables in the given path and a leading ~ with the user's home folder.
+func ExpandPath(path string) (string, error) {
+	path = os.ExpandEnv(path)
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
 // CreateFolderIfNotExists creates a folder at the given path if it doesn't already exist.
 func CreateFolderIfNotExists(path string) error {
 	_, err := os.Stat(path)
@@ -25,6 +25,31 @@ func TestDoesPathExist(t *testing.T) {
 	}
 }
 
+func TestExpandPath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home folder")
+	}
+	os.Setenv("PROJI_TEST_FOLDER", "projects")
+	defer os.Unsetenv("PROJI_TEST_FOLDER")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/tmp/projects", want: "/tmp/projects"},
+		{path: "~", want: home},
+		{path: "~/code/$PROJI_TEST_FOLDER", want: filepath.Join(home, "code", "projects")},
+		{path: "~user/code", want: "~user/code"},
+		{path: "relative/${PROJI_TEST_FOLDER}", want: "relative/projects"},
+	}
+	for _, test := range tests {
+		got, err := ExpandPath(test.path)
+		assert.NoError(t, err)
+		assert.Equal(t, test.want, got, "path %s", test.path)
+	}
+}
+
 func TestCreateFolderIfNotExists(t *testing.T) {
 	tmpDir := filepath.Join(os.TempDir(), "proji-testing")
 
@@ -0,0 +1,39 @@
+package domain
+
+// DiffOptions holds optional settings for the comparison of a project with its package.
+type DiffOptions struct {
+	// Latest compares the project with what the current version of its package produces instead of the files that
+	// were recorded when the project was created or last updated.
+	Latest bool
+}
+
+// Kinds of drift between a project and its package.
+const (
+	DriftMissing  = "missing"
+	DriftExtra    = "extra"
+	DriftModified = "modified"
+)
+
+// FileDrift describes how a single file of a project differs from what its package produced.
+type FileDrift struct {
+	// Path is the path of the file relative to the project's root folder.
+	Path string `json:"path"`
+
+	// Kind is one of the drift kinds, e.g. DriftModified.
+	Kind string `json:"kind"`
+
+	// Diff is the unified diff from the package's output to the project's file. It's empty for binary files.
+	Diff string `json:"diff,omitempty"`
+}
+
+// DriftReport lists the files of a project that differ from what its package produced.
+type DriftReport struct {
+	Project string       `json:"project"`
+	Package string       `json:"package"`
+	Files   []*FileDrift `json:"files"`
+}
+
+// HasDrift reports whether any file of the project differs from its package.
+func (r *DriftReport) HasDrift() bool {
+	return len(r.Files) > 0
+}
@@ -0,0 +1,17 @@
+package domain
+
+// HookOptions holds optional settings and information about the event for plugins that run on a hook.
+type HookOptions struct {
+	// PreviousPath is the path a project was located at before it was moved.
+	PreviousPath string
+
+	// Err is the error that caused the creation of a project to fail.
+	Err error
+
+	// NewStatusSink is called once for every plugin that runs and returns the sink that the plugin's output is
+	// reported to. If it is nil, plugin output is only written to the plugin log.
+	NewStatusSink func() StatusSink
+
+	// NonInteractive runs commands of lua plugins without access to proji's stdin.
+	NonInteractive bool
+}
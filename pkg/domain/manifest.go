@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ManifestPath is the path of the manifest file relative to a project's root folder.
+const ManifestPath = ".proji/manifest.toml"
+
+// Manifest records how a project was created: the package and its version, the variables, the files that were
+// generated and the plugins that ran. It is updated whenever the project is updated with its package.
+type Manifest struct {
+	ID             uint            `gorm:"primarykey" toml:"-"`
+	ProjectID      uint            `gorm:"uniqueIndex" toml:"-"`
+	CreatedAt      time.Time       `toml:"created_at"`
+	UpdatedAt      time.Time       `toml:"updated_at"`
+	PackageName    string          `gorm:"size:64" toml:"package_name"`
+	PackageLabel   string          `gorm:"size:16" toml:"package_label"`
+	PackageVersion string          `gorm:"size:32" toml:"package_version,omitempty"`
+	Variables      Variables       `gorm:"type:text" toml:"variables"`
+	Files          ManifestFiles   `gorm:"type:text" toml:"file"`
+	Plugins        ManifestPlugins `gorm:"type:text" toml:"plugin"`
+}
+
+// ManifestFile is a file that was generated from the package of a project.
+type ManifestFile struct {
+	// Path is the path of the file relative to the project's root folder.
+	Path string `json:"path" toml:"path"`
+
+	// Checksum is the sha256 checksum of the generated content.
+	Checksum string `json:"checksum" toml:"checksum"`
+
+	// Package is the label of the applied package that generated the file. It's empty for files of the project's own
+	// package.
+	Package string `json:"package,omitempty" toml:"package,omitempty"`
+}
+
+// ManifestPlugin is a plugin that ran during the creation of a project.
+type ManifestPlugin struct {
+	Path  string `json:"path" toml:"path"`
+	Phase string `json:"phase" toml:"phase"`
+
+	// ExitStatus is the exit status of the plugin; -1 if the plugin didn't exit on its own, e.g. because it timed out.
+	ExitStatus int `json:"exit_status" toml:"exit_status"`
+
+	// Duration is the time the plugin ran, e.g. "1.5s".
+	Duration string `json:"duration" toml:"duration"`
+
+	// Step reports whether a built-in step ran instead of a plugin. The path of a step is its name.
+	Step bool `json:"step,omitempty" toml:"step,omitempty"`
+
+	// Error describes why the plugin failed. It's empty if the plugin succeeded.
+	Error string `json:"error,omitempty" toml:"error,omitempty"`
+}
+
+// ManifestFiles holds the files of a manifest. They are stored as JSON.
+type ManifestFiles []*ManifestFile
+
+// Value implements the driver.Valuer interface.
+func (f ManifestFiles) Value() (driver.Value, error) {
+	return jsonValue(f)
+}
+
+// Scan implements the sql.Scanner interface.
+func (f *ManifestFiles) Scan(value interface{}) error {
+	return scanJSON(value, f)
+}
+
+// ManifestPlugins holds the plugins of a manifest. They are stored as JSON.
+type ManifestPlugins []*ManifestPlugin
+
+// Value implements the driver.Valuer interface.
+func (p ManifestPlugins) Value() (driver.Value, error) {
+	return jsonValue(p)
+}
+
+// Scan implements the sql.Scanner interface.
+func (p *ManifestPlugins) Scan(value interface{}) error {
+	return scanJSON(value, p)
+}
+
+// jsonValue encodes the given value as JSON for storing it in a text column.
+func jsonValue(value interface{}) (driver.Value, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// scanJSON decodes a JSON text column into the given destination.
+func scanJSON(value, destination interface{}) error {
+	var data []byte
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case string:
+		data = []byte(value)
+	case []byte:
+		data = value
+	default:
+		return fmt.Errorf("can't scan %T into %T", value, destination)
+	}
+	return json.Unmarshal(data, destination)
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"database/sql/driver"
 	"net/url"
 	"regexp"
 	"time"
@@ -9,14 +10,72 @@ import (
 // Package represents a proji package; the central item of proji's project creation mechanism. It holds tags for gorm and
 // toml defining its storage and export/import behaviour.
 type Package struct {
-	ID          uint        `gorm:"primarykey" toml:"-"`
-	CreatedAt   time.Time   `toml:"-"`
-	UpdatedAt   time.Time   `toml:"-"`
-	Name        string      `gorm:"not null;size:64" toml:"name"`
-	Label       string      `gorm:"index:idx_unq_package_label,unique;not null;size:16" toml:"label"`
-	Description string      `gorm:"size:255" toml:"description"`
-	Templates   []*Template `gorm:"many2many:package_templates;" toml:"template"`
-	Plugins     []*Plugin   `gorm:"many2many:package_plugins;" toml:"plugin"`
+	ID          uint      `gorm:"primarykey" toml:"-"`
+	CreatedAt   time.Time `toml:"-"`
+	UpdatedAt   time.Time `toml:"-"`
+	Name        string    `gorm:"not null;size:64" toml:"name"`
+	Label       string    `gorm:"index:idx_unq_package_label,unique;not null;size:16" toml:"label"`
+	Version     string    `gorm:"size:32" toml:"version,omitempty"`
+	Description string    `gorm:"size:255" toml:"description"`
+
+	// NextSteps is a message that is shown after a project was created from the package, e.g. "cd {{ name }} && make
+	// dev". It may contain the same placeholders as templates.
+	NextSteps string `gorm:"type:text" toml:"next_steps,omitempty"`
+
+	// KeepFile is the name of an empty file, e.g. ".gitkeep", that is created in folders that would otherwise stay
+	// empty, so that version control systems like git keep them. Templates can override it.
+	KeepFile string `gorm:"size:64" toml:"keep_file,omitempty"`
+
+	// ProjectsRoot is the folder that projects of the package are created in by default. It may start with ~ and
+	// contain environment variables. If it's empty, projects are created in the working directory.
+	ProjectsRoot string `gorm:"size:255" toml:"projects_root,omitempty"`
+
+	// Requires holds tools that have to be installed before a project can be created from the package, e.g.
+	// "git >= 2.28". See ParseRequirement for the format.
+	Requires StringList `gorm:"type:text" toml:"requires,omitempty"`
+
+	// NameRules restrict the names of projects that are created from the package.
+	NameRules *NameRules `gorm:"type:text" toml:"name_rules,omitempty"`
+
+	Templates []*Template `gorm:"many2many:package_templates;" toml:"template,omitempty"`
+	Plugins   []*Plugin   `gorm:"many2many:package_plugins;" toml:"plugin,omitempty"`
+	Steps     []*Step     `gorm:"foreignKey:PackageID" toml:"step,omitempty"`
+}
+
+// Cases that the names of projects can be restricted to.
+const (
+	NameCaseLower = "lower"
+	NameCaseUpper = "upper"
+	NameCaseKebab = "kebab"
+	NameCaseSnake = "snake"
+)
+
+// NameCases holds all supported name cases.
+var NameCases = []string{NameCaseLower, NameCaseUpper, NameCaseKebab, NameCaseSnake}
+
+// NameRules restrict the names of projects that are created from a package. They are stored as JSON.
+type NameRules struct {
+	// Pattern is a regular expression that the whole name has to match.
+	Pattern string `json:"pattern,omitempty" toml:"pattern,omitempty"`
+
+	// Reserved holds names that can't be used. They are compared case-insensitively.
+	Reserved []string `json:"reserved,omitempty" toml:"reserved,omitempty"`
+
+	// Case is one of the name cases, e.g. NameCaseKebab.
+	Case string `json:"case,omitempty" toml:"case,omitempty"`
+}
+
+// Value implements the driver.Valuer interface.
+func (r *NameRules) Value() (driver.Value, error) {
+	if r == nil {
+		return nil, nil
+	}
+	return jsonValue(r)
+}
+
+// Scan implements the sql.Scanner interface.
+func (r *NameRules) Scan(value interface{}) error {
+	return scanJSON(value, r)
 }
 
 func NewPackage(name, label string) *Package {
@@ -0,0 +1,87 @@
+package domain
+
+// Operation kinds of a creation plan.
+const (
+	OperationCreateRoot   = "create-root"
+	OperationCreateFolder = "create-folder"
+	OperationCreateFile   = "create-file"
+	OperationCopyFile     = "copy-file"
+	OperationRenderFile   = "render-file"
+	OperationRunPlugin    = "run-plugin"
+	OperationRunStep      = "run-step"
+)
+
+// Merge strategies decide what happens to files that already exist when a project is created inside of an existing
+// folder.
+const (
+	// MergeStrategyFail aborts the creation before anything is touched if a file already exists.
+	MergeStrategyFail = "fail"
+
+	// MergeStrategySkip keeps existing files and only creates the missing ones.
+	MergeStrategySkip = "skip"
+
+	// MergeStrategyOverwrite replaces existing files with the ones from the package.
+	MergeStrategyOverwrite = "overwrite"
+)
+
+// MergeStrategies holds all supported merge strategies.
+var MergeStrategies = []string{MergeStrategyFail, MergeStrategySkip, MergeStrategyOverwrite}
+
+// Operation is a single step of the creation of a project.
+type Operation struct {
+	// Kind is one of the operation kinds, e.g. OperationCreateFile.
+	Kind string
+
+	// Source is the absolute path of the template file for copy and render operations, the path of the plugin for plugin
+	// operations and the name of the step for step operations.
+	Source string
+
+	// Destination is the path of the created file or folder relative to the project's root folder, rendered with the
+	// variables known at planning time. It's empty for the project's root folder and for plugin operations.
+	Destination string
+
+	// DestinationTemplate is the unrendered destination. It is rendered again when the plan is executed, since
+	// pre-run plugins may set additional variables.
+	DestinationTemplate string
+
+	// Plugin is the plugin that a plugin operation runs.
+	Plugin *Plugin
+
+	// Step is the built-in step that a step operation runs.
+	Step *Step
+
+	// Template is the remote template that a copy or render operation writes. Its Source is the template's URL until the
+	// template was fetched and the path of the cached file afterwards.
+	Template *Template
+
+	// Phase is the phase a plugin operation runs in; either "pre" or "post".
+	Phase string
+
+	// Exists reports whether the destination of a file operation already exists as a file.
+	Exists bool
+
+	// Conflict describes why the operation conflicts with existing files or with other operations of the plan. It's
+	// empty if the operation has no conflict.
+	Conflict string
+}
+
+// Plan describes all operations that the creation of a project performs, in the order they are executed.
+type Plan struct {
+	Project    *Project
+	Operations []*Operation
+
+	// MergeStrategy decides what happens to files that already exist; one of the merge strategies, e.g.
+	// MergeStrategySkip.
+	MergeStrategy string
+}
+
+// Conflicts returns all operations of the plan that have a conflict.
+func (p *Plan) Conflicts() []*Operation {
+	var conflicts []*Operation
+	for _, operation := range p.Operations {
+		if operation.Conflict != "" {
+			conflicts = append(conflicts, operation)
+		}
+	}
+	return conflicts
+}
@@ -4,6 +4,31 @@ import (
 	"time"
 )
 
+// Plugin types supported by proji. If a plugin has no explicit type, proji infers it from the plugin's file extension.
+const (
+	PluginTypeLua        = "lua"
+	PluginTypeExecutable = "exec"
+)
+
+// Hooks are lifecycle events at which plugins can run in addition to the creation of a project. Plugins without a hook
+// run during the creation of a project, before or after its templates are created.
+const (
+	HookProjectRemoved  = "project-removed"
+	HookProjectMoved    = "project-moved"
+	HookProjectCleaned  = "project-cleaned"
+	HookCreationFailed  = "creation-failed"
+	HookPackageImported = "package-imported"
+)
+
+// Hooks lists all hooks that plugins can register for.
+var Hooks = []string{
+	HookProjectRemoved,
+	HookProjectMoved,
+	HookProjectCleaned,
+	HookCreationFailed,
+	HookPackageImported,
+}
+
 // Plugin represents a proji plugin that is may be used during the project creation process. It holds tags for gorm
 // and toml defining its storage and export/import behaviour.
 type Plugin struct {
@@ -13,4 +38,58 @@ type Plugin struct {
 	Path        string    `gorm:"index:idx_plugin_path,unique;not null" toml:"path"`
 	ExecNumber  int       `gorm:"check:(exec_number != 0);not null;size:4" toml:"exec_number"`
 	Description string    `gorm:"size:255" toml:"description"`
+
+	// Type is the type of the plugin; either "lua" or "exec". It's stored with the package's plugin association.
+	Type string `gorm:"-" toml:"type,omitempty"`
+
+	// Timeout overrides the global plugin timeout, e.g. "30s". It's stored with the package's plugin association.
+	Timeout string `gorm:"-" toml:"timeout,omitempty"`
+
+	// Hook is the lifecycle event that the plugin runs at instead of the creation of a project. It's stored with the
+	// package's plugin association.
+	Hook string `gorm:"-" toml:"hook,omitempty"`
+}
+
+// PackagePlugin associates a plugin with a package. Packages share plugins with the same path, so settings that a
+// package makes for one of its plugins are stored with the association instead of the plugin.
+type PackagePlugin struct {
+	PackageID uint   `gorm:"primaryKey"`
+	PluginID  uint   `gorm:"primaryKey"`
+	Type      string `gorm:"size:16"`
+	Timeout   string `gorm:"size:32"`
+	Hook      string `gorm:"size:32"`
+}
+
+// PluginTestOptions holds the settings for a test run of a single plugin.
+type PluginTestOptions struct {
+	// Phase is the phase the plugin runs in; either "pre", "post" or one of the hooks.
+	Phase string
+
+	// ProjectName is the name of the fake project that the plugin runs for.
+	ProjectName string
+
+	// Variables are passed to the plugin as if previous plugins had set them.
+	Variables map[string]string
+
+	// RunCommands enables the execution of commands that a lua plugin starts with os.execute. By default the commands
+	// are only recorded.
+	RunCommands bool
+
+	// KeepProject keeps the temporary project folder instead of removing it after the test run.
+	KeepProject bool
+}
+
+// PluginTestReport describes what a plugin did during a test run.
+type PluginTestReport struct {
+	ProjectPath  string
+	Phase        string
+	Duration     time.Duration
+	Output       string
+	Commands     []string
+	CreatedFiles []string
+	ChangedFiles []string
+	RemovedFiles []string
+	Variables    map[string]string
+	LogPath      string
+	Err          error
 }
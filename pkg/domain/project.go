@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // Project represents a project that was created by proji. It holds tags for gorm and toml defining its storage and
 // export/import behaviour.
@@ -12,23 +15,145 @@ type Project struct {
 	Path      string    `gorm:"index:idx_unq_project_path,unique;not null" toml:"path"`
 	PackageID int       `toml:"-"`
 	Package   *Package  `toml:"package"`
+	Variables Variables `gorm:"type:text" toml:"variables,omitempty"`
+
+	// Tags group projects, e.g. by client or status. They are plain words without whitespace or commas, like
+	// client:acme or archived.
+	Tags        StringList `gorm:"type:text" toml:"tags,omitempty"`
+	Description string     `gorm:"type:text" toml:"description,omitempty"`
+
+	// Notes are free-form notes about the project, e.g. why it's on hold.
+	Notes string `gorm:"type:text" toml:"notes,omitempty"`
+
+	// AppliedPackages holds the packages that were applied to the project after its creation, in addition to its
+	// package.
+	AppliedPackages []*Package `gorm:"many2many:project_packages;" toml:"-"`
+
+	// Files holds the files that the project's package and its applied packages created. The files of the project's
+	// package are the base for merging later changes of the package into the project.
+	Files []*ProjectFile `toml:"-"`
+
+	// Manifest records how the project was created.
+	Manifest *Manifest `toml:"-"`
+}
+
+// ProjectFile is a file that was created from the package of a project. Its content is the output of the package at
+// the time of the project's creation or its last update.
+type ProjectFile struct {
+	ID        uint   `gorm:"primarykey"`
+	ProjectID uint   `gorm:"index"`
+	Path      string `gorm:"not null"`
+	Content   []byte
+
+	// AppliedPackage is the label of the applied package that created the file. It's empty for files of the project's
+	// own package.
+	AppliedPackage string `gorm:"size:16"`
 }
 
 func NewProject(name, path string, pkg *Package) *Project {
-	return &Project{
+	project := &Project{
 		Name:    name,
 		Path:    path,
 		Package: pkg,
 	}
+	if pkg != nil {
+		project.PackageID = int(pkg.ID)
+	}
+	return project
+}
+
+// HasTag reports whether the project has the given tag.
+func (p *Project) HasTag(tag string) bool {
+	for _, t := range p.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// AddTags adds the given tags to the project. Tags that the project already has are skipped.
+func (p *Project) AddTags(tags ...string) {
+	for _, tag := range tags {
+		if !p.HasTag(tag) {
+			p.Tags = append(p.Tags, tag)
+		}
+	}
+}
+
+// RemoveTags removes the given tags from the project.
+func (p *Project) RemoveTags(tags ...string) {
+	kept := make(StringList, 0, len(p.Tags))
+	for _, t := range p.Tags {
+		remove := false
+		for _, tag := range tags {
+			if t == tag {
+				remove = true
+				break
+			}
+		}
+		if !remove {
+			kept = append(kept, t)
+		}
+	}
+	p.Tags = kept
+}
+
+// StatusSink receives status messages of a running task, e.g. the latest output of a plugin.
+type StatusSink interface {
+	Write(status string)
+	Close()
+}
+
+// CreateOptions holds optional settings for the creation of a project.
+type CreateOptions struct {
+	// NewStatusSink is called once for every plugin that runs and returns the sink that the plugin's output is
+	// reported to. If it is nil, plugin output is only written to the plugin log.
+	NewStatusSink func() StatusSink
+
+	// KeepOnFailure keeps the files and folders that were created if the creation fails. By default, they are removed
+	// again.
+	KeepOnFailure bool
+
+	// Existing allows the project to be created inside of a folder that already exists. Files that already exist are
+	// handled according to MergeStrategy.
+	Existing bool
+
+	// MergeStrategy is one of the merge strategies, e.g. MergeStrategySkip. It defaults to MergeStrategyFail.
+	MergeStrategy string
+
+	// WriteManifest writes the project's manifest to ManifestPath inside of the project, so that it travels with the
+	// project's repository. The manifest is always stored in the database.
+	WriteManifest bool
+
+	// Variables are available to all templates and plugins of the project from the start, e.g. values given in a
+	// projects file. Plugins may override them.
+	Variables map[string]string
+
+	// NonInteractive runs commands of lua plugins without access to proji's stdin, e.g. while several projects are
+	// created at once and their plugins would compete for the user's input.
+	NonInteractive bool
+
+	// SkipNameRules skips the validation of the project's name against the name rules of the package, e.g. when the
+	// package is applied to a project that already has a name.
+	SkipNameRules bool
+
+	// Replace replaces the project that's already stored for the project's path. The old project is only removed once
+	// the new one was created successfully.
+	Replace bool
 }
 
 type ProjectStore interface {
 	StoreProject(p *Project) error
+	ReplaceProject(p *Project) error
 
 	LoadProject(path string) (*Project, error)
 	LoadProjectList(paths ...string) ([]*Project, error)
 
 	UpdateProjectLocation(oldPath, newPath string) error
+	UpdateProject(p *Project) error
+	UpdateProjectDetails(p *Project) error
+	AddProjectPackage(p *Project, pkg *Package) error
 
 	RemoveProject(path string) error
 }
@@ -38,7 +163,16 @@ type ProjectService interface {
 	LoadProject(path string) (*Project, error)
 	LoadProjectList(paths ...string) ([]*Project, error)
 	UpdateProjectLocation(oldPath, newPath string) error
+	UpdateProjectDetails(p *Project) error
 	RemoveProject(path string) error
 
-	CreateProject(configRootPath string, project *Project) (err error)
+	PlanProject(configRootPath string, project *Project, options *CreateOptions) (*Plan, error)
+	UpdateProject(ctx context.Context, configRootPath string, project *Project, options *UpdateOptions) (*UpdateReport, error)
+	ApplyPackage(ctx context.Context, configRootPath string, project *Project, pkg *Package, options *CreateOptions) error
+	DiffProject(ctx context.Context, configRootPath string, project *Project, options *DiffOptions) (*DriftReport, error)
+	CreateProject(ctx context.Context, configRootPath string, project *Project, options *CreateOptions) (err error)
+	NextSteps(project *Project) string
+	RunProjectHook(ctx context.Context, configRootPath, hook string, project *Project, options *HookOptions) error
+	RunPackageHook(ctx context.Context, configRootPath, hook string, pkg *Package, options *HookOptions) error
+	TestPlugin(ctx context.Context, configRootPath string, plugin *Plugin, options *PluginTestOptions) (*PluginTestReport, error)
 }
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Operators that compare the installed version of a required tool with the version of a requirement.
+const (
+	RequirementOperatorEqual        = "="
+	RequirementOperatorGreater      = ">"
+	RequirementOperatorGreaterEqual = ">="
+	RequirementOperatorLess         = "<"
+	RequirementOperatorLessEqual    = "<="
+)
+
+// requirementPattern matches requirements like "git", "git >= 2.28" or "go>=v1.20".
+var requirementPattern = regexp.MustCompile(`^\s*([A-Za-z0-9_.+-]+)\s*(?:(>=|<=|==|=|>|<)\s*v?(\d+(?:\.\d+)*))?\s*$`)
+
+// Requirement is a tool that has to be installed before a project can be created from a package. The version is
+// optional; if it's set, the installed version of the tool has to satisfy it.
+type Requirement struct {
+	Tool string
+
+	// Operator is one of the requirement operators, e.g. RequirementOperatorGreaterEqual. It's empty if the requirement
+	// has no version.
+	Operator string
+	Version  string
+}
+
+// ParseRequirement parses a requirement of the form "TOOL [OPERATOR VERSION]", e.g. "git >= 2.28".
+func ParseRequirement(s string) (*Requirement, error) {
+	match := requirementPattern.FindStringSubmatch(s)
+	if match == nil {
+		return nil, fmt.Errorf("invalid requirement %q", s)
+	}
+	operator := match[2]
+	if operator == "==" {
+		operator = RequirementOperatorEqual
+	}
+	return &Requirement{Tool: match[1], Operator: operator, Version: match[3]}, nil
+}
+
+// String returns the requirement in the form it is written in package configs.
+func (r *Requirement) String() string {
+	if r.Operator == "" {
+		return r.Tool
+	}
+	return fmt.Sprintf("%s %s %s", r.Tool, r.Operator, r.Version)
+}
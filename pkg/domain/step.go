@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Step types that proji runs natively, without a plugin.
+const (
+	// StepTypeRun runs a command with its arguments, e.g. go mod init. The command isn't run by a shell.
+	StepTypeRun = "run"
+
+	// StepTypeGitInit initializes a git repository and commits all files of the project.
+	StepTypeGitInit = "git-init"
+
+	// StepTypeMkdir creates a folder and its missing parents.
+	StepTypeMkdir = "mkdir"
+
+	// StepTypeDownload downloads a file from an https URL.
+	StepTypeDownload = "download"
+
+	// StepTypeChmod changes the permissions of a file or folder.
+	StepTypeChmod = "chmod"
+)
+
+// StepTypes holds all supported step types.
+var StepTypes = []string{StepTypeRun, StepTypeGitInit, StepTypeMkdir, StepTypeDownload, StepTypeChmod}
+
+// DefaultCommitMessage is the message of the initial commit of a git-init step.
+const DefaultCommitMessage = "Initial commit"
+
+// Step is a built-in action of a package, like a plugin that proji runs natively. Steps run in order of their execution
+// number together with the plugins of the package. Paths and arguments may contain placeholders of variables.
+type Step struct {
+	ID         uint      `gorm:"primarykey" toml:"-"`
+	CreatedAt  time.Time `toml:"-"`
+	UpdatedAt  time.Time `toml:"-"`
+	PackageID  uint      `gorm:"index" toml:"-"`
+	Type       string    `gorm:"size:16;not null" toml:"type"`
+	ExecNumber int       `gorm:"check:(exec_number != 0);not null;size:4" toml:"exec_number"`
+
+	// Command and Args are the command of a run step and its arguments. Env holds additional environment variables.
+	Command string     `gorm:"size:255" toml:"command,omitempty"`
+	Args    StringList `gorm:"type:text" toml:"args,omitempty"`
+
+	// Dir is the folder that a run or git-init step runs in, relative to the project's root folder.
+	Dir string `gorm:"size:255" toml:"dir,omitempty"`
+
+	// Path is the file or folder of a mkdir, download or chmod step, relative to the project's root folder.
+	Path string `gorm:"size:255" toml:"path,omitempty"`
+
+	// URL and Checksum are the source of a download step and the sha256 checksum that the downloaded file has to match.
+	URL      string `gorm:"size:255" toml:"url,omitempty"`
+	Checksum string `gorm:"size:71" toml:"checksum,omitempty"`
+
+	// Mode is the octal file mode of a chmod step, e.g. "0755".
+	Mode string `gorm:"size:8" toml:"mode,omitempty"`
+
+	// Message is the message of the initial commit of a git-init step. It defaults to DefaultCommitMessage.
+	Message string `gorm:"size:255" toml:"message,omitempty"`
+
+	Timeout     string `gorm:"size:32" toml:"timeout,omitempty"`
+	Description string `gorm:"size:255" toml:"description,omitempty"`
+
+	// Env is encoded as a TOML table, so it has to be the last field of the step.
+	Env Variables `gorm:"type:text" toml:"env,omitempty"`
+}
+
+// Name returns a short readable name of the step for messages and logs, e.g. "run go mod init".
+func (s *Step) Name() string {
+	switch s.Type {
+	case StepTypeRun:
+		return strings.TrimSpace(fmt.Sprintf("run %s %s", s.Command, strings.Join(s.Args, " ")))
+	case StepTypeMkdir:
+		return "mkdir " + s.Path
+	case StepTypeDownload:
+		return "download " + s.Path
+	case StepTypeChmod:
+		return fmt.Sprintf("chmod %s %s", s.Mode, s.Path)
+	default:
+		return s.Type
+	}
+}
+
+// StringList holds a list of strings. It is stored as JSON.
+type StringList []string
+
+// Value implements the driver.Valuer interface.
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return nil, nil
+	}
+	return jsonValue(l)
+}
+
+// Scan implements the sql.Scanner interface.
+func (l *StringList) Scan(value interface{}) error {
+	return scanJSON(value, l)
+}
@@ -1,9 +1,22 @@
 package domain
 
 import (
+	"strings"
 	"time"
 )
 
+// Prefixes of template paths that reference files outside of the templates folder. Remote templates are fetched when a
+// project is created and cached in the config folder.
+const (
+	// TemplateSourceHTTPS references a file that is downloaded, e.g. https://example.com/LICENSE.
+	TemplateSourceHTTPS = "https://"
+
+	// TemplateSourceGit references a file inside of a git repository. The repository and the path of the file are
+	// separated by a double slash, an optional ref selects the branch or tag, e.g.
+	// git::https://github.com/org/repo.git//.golangci.yml?ref=v1.0.0.
+	TemplateSourceGit = "git::"
+)
+
 // Template represents a template file or folder used by proji. It holds tags for gorm and toml defining its storage
 // and export/import behaviour.
 type Template struct {
@@ -14,4 +27,34 @@ type Template struct {
 	Destination string    `gorm:"index:idx_template_path_destination,unique;not null" toml:"destination"`
 	Path        string    `gorm:"index:idx_template_path_destination,unique;not null" toml:"path"`
 	Description string    `gorm:"size:255" toml:"description"`
+
+	// Checksum is the optional sha256 checksum of a remote template, e.g. "sha256:2c26b4...". Remote templates whose
+	// content doesn't match it are rejected. It's stored with the package's template association.
+	Checksum string `gorm:"-" toml:"checksum,omitempty"`
+
+	// KeepFile is the name of an empty file, e.g. ".gitkeep", that is created in folders of the template that would
+	// otherwise stay empty. It overrides the keep file of the package. It's stored with the package's template
+	// association.
+	KeepFile string `gorm:"-" toml:"keep_file,omitempty"`
+
+	// Render enables the replacement of variables like {{name}} in the content of the template. All files of a template
+	// folder are rendered. Templates that don't set it are copied as they are, so files that use the same syntax for
+	// other purposes, e.g. Helm charts or GitHub Actions workflows, stay intact. It's stored with the package's
+	// template association.
+	Render bool `gorm:"-" toml:"render,omitempty"`
+}
+
+// PackageTemplate associates a template with a package. Packages share templates with the same destination and path,
+// so settings that a package makes for one of its templates are stored with the association instead of the template.
+type PackageTemplate struct {
+	PackageID  uint   `gorm:"primaryKey"`
+	TemplateID uint   `gorm:"primaryKey"`
+	Checksum   string `gorm:"size:71"`
+	KeepFile   string `gorm:"size:64"`
+	Render     bool   `gorm:"not null;default:false"`
+}
+
+// IsRemote reports whether the template references a file outside of the templates folder.
+func (t *Template) IsRemote() bool {
+	return strings.HasPrefix(t.Path, TemplateSourceHTTPS) || strings.HasPrefix(t.Path, TemplateSourceGit)
 }
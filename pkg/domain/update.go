@@ -0,0 +1,46 @@
+package domain
+
+// Actions that an update applies to the files of a project.
+const (
+	UpdateCreated   = "created"
+	UpdateUpdated   = "updated"
+	UpdateMerged    = "merged"
+	UpdateConflict  = "conflict"
+	UpdateKept      = "kept"
+	UpdateRemoved   = "removed"
+	UpdateSkipped   = "skipped"
+	UpdateUnchanged = "unchanged"
+)
+
+// UpdateOptions holds optional settings for the update of a project.
+type UpdateOptions struct {
+	// DryRun only reports what the update would do without touching disk or database.
+	DryRun bool
+}
+
+// FileUpdate describes what an update did to a single file of a project.
+type FileUpdate struct {
+	// Path is the path of the file relative to the project's root folder.
+	Path string
+
+	// Action is one of the update actions, e.g. UpdateMerged.
+	Action string
+
+	// Reason explains why a file was kept, skipped or has conflicts.
+	Reason string
+}
+
+// UpdateReport lists what an update did to the files of a project.
+type UpdateReport struct {
+	Files []*FileUpdate
+}
+
+// HasConflicts reports whether the update left conflicts in any file.
+func (r *UpdateReport) HasConflicts() bool {
+	for _, file := range r.Files {
+		if file.Action == UpdateConflict {
+			return true
+		}
+	}
+	return false
+}
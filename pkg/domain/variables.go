@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"database/sql/driver"
+)
+
+// Names of the variables that are available to every template.
+const (
+	VariableName         = "name"
+	VariableSlug         = "slug"
+	VariablePath         = "path"
+	VariablePackageName  = "package_name"
+	VariablePackageLabel = "package_label"
+)
+
+// BaseVariables holds the names of the variables that are available to every project, independent of its plugins.
+var BaseVariables = []string{VariableName, VariableSlug, VariablePath, VariablePackageName, VariablePackageLabel}
+
+// Variables holds named values that are available to templates and plugins during the creation of a project, e.g.
+// values that pre-run plugins computed. They are stored as JSON.
+type Variables map[string]string
+
+// Value implements the driver.Valuer interface.
+func (v Variables) Value() (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return jsonValue(v)
+}
+
+// Scan implements the sql.Scanner interface.
+func (v *Variables) Scan(value interface{}) error {
+	return scanJSON(value, v)
+}
@@ -6,13 +6,16 @@ import (
 	"path/filepath"
 	"regexp"
 
+	"github.com/nikoksr/proji/internal/rename"
 	"github.com/nikoksr/proji/internal/util"
 	"github.com/nikoksr/proji/pkg/domain"
 	"github.com/pkg/errors"
 )
 
 // ImportFromFolderStructure imports a package from a given directory. Proji will imitate the
-// structure and content of the directory and create a package based on it.
+// structure and content of the directory and create a package based on it. Names of files and folders are renamed
+// according to the template settings, just like files inside of template folders; paths are matched against exclude
+// before they are renamed.
 func (ps packageService) ImportPackageFromDirectoryStructure(path string, exclude *regexp.Regexp) (*domain.Package, error) {
 	// Validate that the directory exists
 	if !util.DoesPathExist(path) {
@@ -50,12 +53,14 @@ func (ps packageService) ImportPackageFromDirectoryStructure(path string, exclud
 			isFile = false
 		}
 
-		pkg.Templates = append(pkg.Templates, &domain.Template{IsFile: isFile, Path: "", Destination: relPath})
+		destination := rename.TemplatePath(relPath, ps.templateSettings, domain.BaseVariables)
+		pkg.Templates = append(pkg.Templates, &domain.Template{IsFile: isFile, Path: "", Destination: destination})
 		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
+	ps.extractKeepFiles(pkg)
 
 	// Validate package
 	err = isPackageValid(pkg)
@@ -64,3 +69,42 @@ func (ps packageService) ImportPackageFromDirectoryStructure(path string, exclud
 	}
 	return pkg, nil
 }
+
+// extractKeepFiles removes the keep files, e.g. .gitkeep, from the templates of an imported structure and sets them as
+// keep file of their folders instead. Keep files aren't content of the structure; they only keep their folder from
+// being empty. Keep files in the root of the structure are dropped since the project folder itself is never empty.
+func (ps packageService) extractKeepFiles(pkg *domain.Package) {
+	templates := make([]*domain.Template, 0, len(pkg.Templates))
+	var keepFiles []string
+	for _, template := range pkg.Templates {
+		if template.IsFile && ps.isKeepFile(filepath.Base(template.Destination)) {
+			keepFiles = append(keepFiles, template.Destination)
+			continue
+		}
+		templates = append(templates, template)
+	}
+	pkg.Templates = templates
+
+	for _, keepFile := range keepFiles {
+		folder := filepath.Dir(keepFile)
+		for _, template := range pkg.Templates {
+			if !template.IsFile && template.Destination == folder {
+				template.KeepFile = filepath.Base(keepFile)
+				break
+			}
+		}
+	}
+}
+
+// isKeepFile reports whether the given file name is one of the keep files of the template settings.
+func (ps packageService) isKeepFile(name string) bool {
+	if ps.templateSettings == nil {
+		return false
+	}
+	for _, keepFile := range ps.templateSettings.KeepFiles {
+		if name == keepFile {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package packageservice
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/nikoksr/proji/internal/config"
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportPackageFromDirectoryStructureKeepFiles(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "proji-dirstructure-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	structure := filepath.Join(tempDir, "structure")
+	_ = os.MkdirAll(filepath.Join(structure, "logs"), os.ModePerm)
+	_ = os.MkdirAll(filepath.Join(structure, "src"), os.ModePerm)
+	_ = ioutil.WriteFile(filepath.Join(structure, ".gitkeep"), nil, 0o600)
+	_ = ioutil.WriteFile(filepath.Join(structure, "logs", ".keep"), nil, 0o600)
+	_ = ioutil.WriteFile(filepath.Join(structure, "src", "main.go"), nil, 0o600)
+
+	ps := packageService{templateSettings: &config.Templates{KeepFiles: []string{".gitkeep", ".keep"}}}
+	pkg, err := ps.ImportPackageFromDirectoryStructure(structure, regexp.MustCompile(`^\.git$`))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, []*domain.Template{
+		{IsFile: false, Destination: "logs", KeepFile: ".keep"},
+		{IsFile: false, Destination: "src"},
+		{IsFile: true, Destination: filepath.Join("src", "main.go")},
+	}, pkg.Templates)
+}
@@ -5,6 +5,7 @@ import (
 	"path"
 	"regexp"
 
+	"github.com/nikoksr/proji/internal/rename"
 	"github.com/nikoksr/proji/pkg/domain"
 
 	"github.com/pkg/errors"
@@ -14,7 +15,8 @@ import (
 
 // ImportFromRepoStructure imports a package from a given URL. The URL should point to a remote remote of one of the following code
 // platforms: github, gitlab. Proji will imitate the structure and content of the remote and create a package
-// based on it.
+// based on it. Names of files and folders are renamed according to the template settings and keep files, e.g. .gitkeep,
+// become the keep file of their folder.
 func (ps packageService) ImportPackageFromRepositoryStructure(url *url.URL, exclude *regexp.Regexp) (*domain.Package, error) {
 	// Get code repo
 	codeRepo, err := remote.NewCodeRepository(url, ps.authentication)
@@ -33,6 +35,10 @@ func (ps packageService) ImportPackageFromRepositoryStructure(url *url.URL, excl
 	if err != nil {
 		return nil, errors.Wrap(err, "get templates from tree entries")
 	}
+	for _, template := range pkg.Templates {
+		template.Destination = rename.TemplatePath(template.Destination, ps.templateSettings, domain.BaseVariables)
+	}
+	ps.extractKeepFiles(pkg)
 
 	// Validate package correctness
 	err = isPackageValid(pkg)
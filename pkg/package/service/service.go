@@ -8,14 +8,16 @@ import (
 )
 
 type packageService struct {
-	authentication *config.APIAuthentication
-	packageStore   domain.PackageStore
+	authentication   *config.APIAuthentication
+	templateSettings *config.Templates
+	packageStore     domain.PackageStore
 }
 
-func New(auth *config.APIAuthentication, store domain.PackageStore) domain.PackageService {
+func New(auth *config.APIAuthentication, templateSettings *config.Templates, store domain.PackageStore) domain.PackageService {
 	return &packageService{
-		authentication: auth,
-		packageStore:   store,
+		authentication:   auth,
+		templateSettings: templateSettings,
+		packageStore:     store,
 	}
 }
 
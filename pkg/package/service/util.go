@@ -3,12 +3,18 @@ package packageservice
 import (
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/pkg/errors"
 )
 
+// checksumPattern matches sha256 checksums of remote templates, optionally prefixed with "sha256:".
+var checksumPattern = regexp.MustCompile(`^(sha256:)?[0-9a-fA-F]{64}$`)
+
 func isPackageValid(pkg *domain.Package) error {
 	if len(pkg.Name) == 0 {
 		return fmt.Errorf("package needs a name")
@@ -16,12 +22,157 @@ func isPackageValid(pkg *domain.Package) error {
 	if len(pkg.Label) == 0 {
 		return fmt.Errorf("package needs a label")
 	}
-	if len(pkg.Templates) == 0 && len(pkg.Plugins) == 0 {
+	if len(pkg.Templates) == 0 && len(pkg.Plugins) == 0 && len(pkg.Steps) == 0 {
 		return fmt.Errorf("package has no data")
 	}
+	err := isKeepFileValid(pkg.KeepFile)
+	if err != nil {
+		return err
+	}
+	err = isNameRulesValid(pkg.NameRules)
+	if err != nil {
+		return errors.Wrap(err, "name rules")
+	}
+	for _, requirement := range pkg.Requires {
+		_, err := domain.ParseRequirement(requirement)
+		if err != nil {
+			return err
+		}
+	}
+	for _, template := range pkg.Templates {
+		err := isKeepFileValid(template.KeepFile)
+		if err != nil {
+			return errors.Wrapf(err, "template %s", template.Destination)
+		}
+		if template.Checksum != "" && !checksumPattern.MatchString(template.Checksum) {
+			return fmt.Errorf("template %s has invalid checksum %s", template.Path, template.Checksum)
+		}
+		if !template.IsRemote() {
+			if template.Checksum != "" {
+				return fmt.Errorf("template %s has a checksum, but is not a remote template", template.Path)
+			}
+			continue
+		}
+		if !template.IsFile {
+			return fmt.Errorf("remote template %s has to be a file", template.Path)
+		}
+	}
+	for _, plugin := range pkg.Plugins {
+		switch plugin.Type {
+		case "", domain.PluginTypeLua, domain.PluginTypeExecutable:
+		default:
+			return fmt.Errorf("plugin %s has unsupported type %s", plugin.Path, plugin.Type)
+		}
+		if plugin.Timeout != "" {
+			_, err := time.ParseDuration(plugin.Timeout)
+			if err != nil {
+				return fmt.Errorf("plugin %s has invalid timeout %s", plugin.Path, plugin.Timeout)
+			}
+		}
+		if plugin.Hook != "" && !isHook(plugin.Hook) {
+			return fmt.Errorf("plugin %s has unsupported hook %s", plugin.Path, plugin.Hook)
+		}
+	}
+	for i, step := range pkg.Steps {
+		err := isStepValid(step)
+		if err != nil {
+			return errors.Wrapf(err, "step %d (%s)", i+1, step.Type)
+		}
+	}
 	return nil
 }
 
+// isKeepFileValid checks that the name of a keep file is a plain file name. An empty name is valid.
+func isKeepFileValid(name string) error {
+	if name == "" {
+		return nil
+	}
+	if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("keep file %s has to be a plain file name", name)
+	}
+	return nil
+}
+
+// isNameRulesValid checks that the pattern of the name rules compiles the way it's matched against project names and
+// that their case is a known name case. Nil rules are valid.
+func isNameRulesValid(rules *domain.NameRules) error {
+	if rules == nil {
+		return nil
+	}
+	if rules.Pattern != "" {
+		_, err := regexp.Compile(`^(?:` + rules.Pattern + `)$`)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %s: %v", rules.Pattern, err)
+		}
+	}
+	if rules.Case != "" {
+		for _, nameCase := range domain.NameCases {
+			if rules.Case == nameCase {
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown case %s, expected one of %s", rules.Case, strings.Join(domain.NameCases, ", "))
+	}
+	return nil
+}
+
+// isStepValid checks that a step has a supported type, a valid execution number and all fields its type requires.
+func isStepValid(step *domain.Step) error {
+	if step.ExecNumber == 0 {
+		return fmt.Errorf("exec_number may not be 0")
+	}
+	if step.Timeout != "" {
+		_, err := time.ParseDuration(step.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout %s", step.Timeout)
+		}
+	}
+	switch step.Type {
+	case domain.StepTypeRun:
+		if step.Command == "" {
+			return fmt.Errorf("command is missing")
+		}
+	case domain.StepTypeGitInit:
+	case domain.StepTypeMkdir:
+		if step.Path == "" {
+			return fmt.Errorf("path is missing")
+		}
+	case domain.StepTypeDownload:
+		if step.Path == "" {
+			return fmt.Errorf("path is missing")
+		}
+		if !strings.HasPrefix(step.URL, "https://") {
+			return fmt.Errorf("url has to start with https://")
+		}
+		if step.Checksum == "" {
+			return fmt.Errorf("checksum is missing")
+		}
+		if !checksumPattern.MatchString(step.Checksum) {
+			return fmt.Errorf("invalid checksum %s", step.Checksum)
+		}
+	case domain.StepTypeChmod:
+		if step.Path == "" {
+			return fmt.Errorf("path is missing")
+		}
+		if _, err := strconv.ParseUint(step.Mode, 8, 32); err != nil {
+			return fmt.Errorf("invalid mode %s, expected an octal mode like 0755", step.Mode)
+		}
+	default:
+		return fmt.Errorf("unsupported type, expected one of %s", strings.Join(domain.StepTypes, ", "))
+	}
+	return nil
+}
+
+// isHook reports whether the given name is a hook that plugins can register for.
+func isHook(name string) bool {
+	for _, hook := range domain.Hooks {
+		if name == hook {
+			return true
+		}
+	}
+	return false
+}
+
 // pickLabel dynamically picks a label based on the package name.
 func pickLabel(packageName string) string {
 	nameLen := len(packageName)
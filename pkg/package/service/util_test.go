@@ -0,0 +1,110 @@
+package packageservice
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsStepValid(t *testing.T) {
+	checksum := "sha256:" + strings.Repeat("a", 64)
+	tests := []struct {
+		name    string
+		step    *domain.Step
+		wantErr string
+	}{
+		{
+			name: "Test download",
+			step: &domain.Step{
+				Type: domain.StepTypeDownload, ExecNumber: 1, Path: "LICENSE", URL: "https://example.com/LICENSE",
+				Checksum: checksum,
+			},
+		},
+		{
+			name: "Test download without checksum",
+			step: &domain.Step{
+				Type: domain.StepTypeDownload, ExecNumber: 1, Path: "LICENSE", URL: "https://example.com/LICENSE",
+			},
+			wantErr: "checksum is missing",
+		},
+		{
+			name: "Test download with invalid checksum",
+			step: &domain.Step{
+				Type: domain.StepTypeDownload, ExecNumber: 1, Path: "LICENSE", URL: "https://example.com/LICENSE",
+				Checksum: "md5:abc",
+			},
+			wantErr: "invalid checksum md5:abc",
+		},
+		{
+			name: "Test download over http",
+			step: &domain.Step{
+				Type: domain.StepTypeDownload, ExecNumber: 1, Path: "LICENSE", URL: "http://example.com/LICENSE",
+				Checksum: checksum,
+			},
+			wantErr: "url has to start with https://",
+		},
+		{name: "Test run", step: &domain.Step{Type: domain.StepTypeRun, ExecNumber: -1, Command: "go"}},
+		{
+			name:    "Test run without command",
+			step:    &domain.Step{Type: domain.StepTypeRun, ExecNumber: 1},
+			wantErr: "command is missing",
+		},
+		{
+			name:    "Test without exec number",
+			step:    &domain.Step{Type: domain.StepTypeGitInit},
+			wantErr: "exec_number may not be 0",
+		},
+		{
+			name:    "Test chmod with invalid mode",
+			step:    &domain.Step{Type: domain.StepTypeChmod, ExecNumber: 1, Path: "run.sh", Mode: "rwx"},
+			wantErr: "invalid mode rwx, expected an octal mode like 0755",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := isStepValid(tt.step)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.Equal(t, tt.wantErr, err.Error())
+			}
+		})
+	}
+}
+
+func TestIsNameRulesValid(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   *domain.NameRules
+		wantErr string
+	}{
+		{name: "Test no rules"},
+		{name: "Test empty rules", rules: &domain.NameRules{}},
+		{
+			name:  "Test valid rules",
+			rules: &domain.NameRules{Pattern: "[a-z]+(-[a-z]+)*", Case: domain.NameCaseKebab, Reserved: []string{"test"}},
+		},
+		{
+			name:    "Test invalid pattern",
+			rules:   &domain.NameRules{Pattern: "[a-z"},
+			wantErr: "invalid pattern [a-z: error parsing regexp: missing closing ]: `[a-z)$`",
+		},
+		{
+			name:    "Test unknown case",
+			rules:   &domain.NameRules{Case: "camel"},
+			wantErr: "unknown case camel, expected one of lower, upper, kebab, snake",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := isNameRulesValid(tt.rules)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.Equal(t, tt.wantErr, err.Error())
+			}
+		})
+	}
+}
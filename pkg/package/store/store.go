@@ -63,16 +63,32 @@ func (ps packageStore) StorePackage(pkg *domain.Package) error {
 		return err
 	}
 
+	err = storeSteps(tx, pkg.Steps, pkg.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	return tx.Commit().Error
 }
 
+// storeTemplates inserts the templates of a package. Packages share templates with the same destination and path, so
+// the checksum and the keep file of a template are stored with the package's template association.
 func storeTemplates(tx *gorm.DB, templates []*domain.Template, packageID uint) error {
 	insertTemplateStmt := "INSERT OR IGNORE INTO templates (created_at, updated_at, is_file, destination, path, description) VALUES (?, ?, ?, ?, ?, ?)"
-	insertAssociationStmt := "INSERT OR IGNORE INTO package_templates (package_id, template_id) VALUES (?, ?)"
+	insertAssociationStmt := "INSERT OR IGNORE INTO package_templates (package_id, template_id, checksum, keep_file, render) VALUES (?, ?, ?, ?, ?)"
 	queryIDStmt := "SELECT id from templates WHERE destination = ? AND path = ?"
 	for _, template := range templates {
 		now := time.Now()
-		err := tx.Exec(insertTemplateStmt, now, now, template.IsFile, template.Destination, template.Path, template.Description).Error
+		err := tx.Exec(
+			insertTemplateStmt,
+			now,
+			now,
+			template.IsFile,
+			template.Destination,
+			template.Path,
+			template.Description,
+		).Error
 		if err != nil {
 			return err
 		}
@@ -87,7 +103,7 @@ func storeTemplates(tx *gorm.DB, templates []*domain.Template, packageID uint) e
 		}
 		template.ID = uint(id.Int64)
 
-		err = tx.Exec(insertAssociationStmt, packageID, template.ID).Error
+		err = tx.Exec(insertAssociationStmt, packageID, template.ID, template.Checksum, template.KeepFile, template.Render).Error
 		if err != nil {
 			return err
 		}
@@ -95,10 +111,12 @@ func storeTemplates(tx *gorm.DB, templates []*domain.Template, packageID uint) e
 	return nil
 }
 
+// storePlugins inserts the plugins of a package. Packages share plugins with the same path, so the type, the timeout and
+// the hook of a plugin are stored with the package's plugin association.
 func storePlugins(tx *gorm.DB, plugins []*domain.Plugin, packageID uint) error {
 	var err error
 	insertPluginStmt := "INSERT OR IGNORE INTO plugins (created_at, updated_at, path, exec_number, description) VALUES (?, ?, ?, ?, ?)"
-	insertAssociationStmt := "INSERT OR IGNORE INTO package_plugins (package_id, plugin_id) VALUES (?, ?)"
+	insertAssociationStmt := "INSERT OR IGNORE INTO package_plugins (package_id, plugin_id, type, timeout, hook) VALUES (?, ?, ?, ?, ?)"
 	queryIDStmt := "SELECT id from plugins WHERE path = ?"
 	for _, plugin := range plugins {
 		now := time.Now()
@@ -117,7 +135,7 @@ func storePlugins(tx *gorm.DB, plugins []*domain.Plugin, packageID uint) error {
 		}
 		plugin.ID = uint(id.Int64)
 
-		err = tx.Exec(insertAssociationStmt, packageID, plugin.ID).Error
+		err = tx.Exec(insertAssociationStmt, packageID, plugin.ID, plugin.Type, plugin.Timeout, plugin.Hook).Error
 		if err != nil {
 			return err
 		}
@@ -125,6 +143,22 @@ func storePlugins(tx *gorm.DB, plugins []*domain.Plugin, packageID uint) error {
 	return nil
 }
 
+// storeSteps inserts the steps of a package. Other than templates and plugins, steps belong to a single package.
+func storeSteps(tx *gorm.DB, steps []*domain.Step, packageID uint) error {
+	if len(steps) == 0 {
+		return nil
+	}
+	for _, step := range steps {
+		step.ID = 0
+		step.PackageID = packageID
+	}
+	err := tx.Create(&steps).Error
+	if err != nil {
+		return errors.Wrap(err, "insert steps")
+	}
+	return nil
+}
+
 func (ps packageStore) LoadPackage(loadDependencies bool, label string) (*domain.Package, error) {
 	conditions := "WHERE label = ?"
 	if loadDependencies {
@@ -170,17 +204,30 @@ func (ps packageStore) LoadPackageList(loadDependencies bool, labels ...string)
 }
 
 const (
-	defaultPackageQueryBase     = `SELECT name, label, description FROM packages`
+	defaultPackageQueryBase     = `SELECT id, name, label, version, description, next_steps, keep_file, projects_root, requires, name_rules FROM packages`
 	defaultPackageDeepQueryBase = `SELECT
+	packages.id,
 	packages.name,
 	packages.label,
+	packages.version,
 	packages.description as package_description,
+	packages.next_steps,
+	packages.keep_file as package_keep_file,
+	packages.projects_root,
+	packages.requires,
+	packages.name_rules,
 	templates.is_file,
 	templates.destination,
 	templates."path" as template_path,
 	templates.description as template_description,
+	package_templates.checksum as template_checksum,
+	package_templates.keep_file as template_keep_file,
+	package_templates.render as template_render,
 	plugins."path" as plugin_path,
+	package_plugins.type as plugin_type,
 	plugins.exec_number,
+	package_plugins.timeout as plugin_timeout,
+	package_plugins.hook as plugin_hook,
 	plugins.description as plugin_description
 	FROM packages
 LEFT OUTER JOIN package_templates
@@ -195,16 +242,32 @@ LEFT OUTER JOIN plugins
 
 // loadAllPackages loads and returns all packages found in the database.
 func (ps packageStore) queryPackage(conditions string, values ...string) (*domain.Package, error) {
+	var id uint
 	var name, label string
-	var description null.String
-	err := ps.db.Raw(defaultPackageQueryBase+" "+conditions, values).Row().Scan(&name, &label, &description)
+	var version, description, nextSteps, keepFile, projectsRoot null.String
+	var requires domain.StringList
+	var nameRules *domain.NameRules
+	err := ps.db.Raw(defaultPackageQueryBase+" "+conditions, values).Row().Scan(
+		&id, &name, &label, &version, &description, &nextSteps, &keepFile, &projectsRoot, &requires, &nameRules,
+	)
 	if err == sql.ErrNoRows {
 		return nil, ErrPackageNotFound
 	}
 	if err != nil {
 		return nil, err
 	}
-	return &domain.Package{Name: name, Label: label, Description: description.String}, nil
+	return &domain.Package{
+		ID:           id,
+		Name:         name,
+		Label:        label,
+		Version:      version.String,
+		Description:  description.String,
+		NextSteps:    nextSteps.String,
+		KeepFile:     keepFile.String,
+		ProjectsRoot: projectsRoot.String,
+		Requires:     requires,
+		NameRules:    nameRules,
+	}, nil
 }
 
 func (ps packageStore) deepQueryPackage(conditions string, values ...string) (pkg *domain.Package, err error) {
@@ -221,28 +284,54 @@ func (ps packageStore) deepQueryPackage(conditions string, values ...string) (pk
 
 	pkg = &domain.Package{}
 	var gotPkgInfo bool
+
+	// The joins produce one row per combination of template and plugin, so the same template or plugin may show up in
+	// multiple rows. Keep track of what was already added to the package.
+	seenTemplates := make(map[string]bool)
+	seenPlugins := make(map[string]bool)
 	for rows.Next() {
 		var (
+			packageID                 uint
 			packageName, packageLabel string
+			packageVersion            null.String
 			packageDescription        null.String
+			packageNextSteps          null.String
+			packageKeepFile           null.String
+			packageProjectsRoot       null.String
+			packageRequires           domain.StringList
+			packageNameRules          *domain.NameRules
 
-			templateIsFile                                         null.Bool
+			templateIsFile, templateRender                         null.Bool
 			templateDestination, templatePath, templateDescription null.String
+			templateChecksum, templateKeepFile                     null.String
 
-			pluginPath, pluginDescription null.String
-			pluginExecNumber              null.Int
+			pluginPath, pluginType, pluginTimeout, pluginHook, pluginDescription null.String
+			pluginExecNumber                                                     null.Int
 		)
 
 		err = rows.Scan(
+			&packageID,
 			&packageName,
 			&packageLabel,
+			&packageVersion,
 			&packageDescription,
+			&packageNextSteps,
+			&packageKeepFile,
+			&packageProjectsRoot,
+			&packageRequires,
+			&packageNameRules,
 			&templateIsFile,
 			&templateDestination,
 			&templatePath,
 			&templateDescription,
+			&templateChecksum,
+			&templateKeepFile,
+			&templateRender,
 			&pluginPath,
+			&pluginType,
 			&pluginExecNumber,
+			&pluginTimeout,
+			&pluginHook,
 			&pluginDescription,
 		)
 		if err != nil {
@@ -250,23 +339,39 @@ func (ps packageStore) deepQueryPackage(conditions string, values ...string) (pk
 		}
 
 		if !gotPkgInfo {
+			pkg.ID = packageID
 			pkg.Name = packageName
 			pkg.Label = packageLabel
+			pkg.Version = packageVersion.String
 			pkg.Description = packageDescription.String
+			pkg.NextSteps = packageNextSteps.String
+			pkg.KeepFile = packageKeepFile.String
+			pkg.ProjectsRoot = packageProjectsRoot.String
+			pkg.Requires = packageRequires
+			pkg.NameRules = packageNameRules
 			gotPkgInfo = true
 		}
-		if templateIsFile.Valid && templateDestination.Valid && templatePath.Valid {
+		templateKey := templateDestination.String + "\x00" + templatePath.String
+		if templateIsFile.Valid && templateDestination.Valid && templatePath.Valid && !seenTemplates[templateKey] {
+			seenTemplates[templateKey] = true
 			pkg.Templates = append(pkg.Templates, &domain.Template{
 				IsFile:      templateIsFile.Bool,
 				Destination: templateDestination.String,
 				Path:        templatePath.String,
 				Description: templateDescription.String,
+				Checksum:    templateChecksum.String,
+				KeepFile:    templateKeepFile.String,
+				Render:      templateRender.Bool,
 			})
 		}
-		if pluginPath.Valid && pluginExecNumber.Valid {
+		if pluginPath.Valid && pluginExecNumber.Valid && !seenPlugins[pluginPath.String] {
+			seenPlugins[pluginPath.String] = true
 			pkg.Plugins = append(pkg.Plugins, &domain.Plugin{
 				Path:        pluginPath.String,
+				Type:        pluginType.String,
 				ExecNumber:  int(pluginExecNumber.Int64),
+				Timeout:     pluginTimeout.String,
+				Hook:        pluginHook.String,
 				Description: pluginDescription.String,
 			})
 		}
@@ -277,6 +382,10 @@ func (ps packageStore) deepQueryPackage(conditions string, values ...string) (pk
 	if !gotPkgInfo {
 		return nil, ErrPackageNotFound
 	}
+	err = ps.db.Where("package_id = ?", pkg.ID).Order("exec_number, id").Find(&pkg.Steps).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "load steps")
+	}
 	return pkg, nil
 }
 
@@ -331,6 +440,25 @@ func (ps packageStore) RemovePackage(label string) error {
 		return err
 	}
 
+	err = tx.Exec("DELETE FROM package_plugins WHERE package_id = ?", pkg.ID).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Exec("DELETE FROM steps WHERE package_id = ?", pkg.ID).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Projects that the package was applied to no longer reference it, just like projects created with it.
+	err = tx.Exec("DELETE FROM project_packages WHERE package_id = ?", pkg.ID).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	// Delete the actual package
 	err = tx.Delete(pkg).Error
 	if errors.Is(tx.Error, gorm.ErrRecordNotFound) || tx.RowsAffected < 1 {
@@ -0,0 +1,149 @@
+package packagestore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nikoksr/proji/internal/database"
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorePackageSharedTemplates(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "proji-package-store-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	db, err := database.New("sqlite3", filepath.Join(tempDir, "proji.sqlite3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Migrate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := New(db.Connection)
+
+	// Both packages share the templates, but pin different checksums, use different keep files and only one of them
+	// renders the license.
+	path := "https://example.com/LICENSE"
+	checksums := map[string]string{
+		"a": "sha256:1111111111111111111111111111111111111111111111111111111111111111",
+		"b": "sha256:2222222222222222222222222222222222222222222222222222222222222222",
+	}
+	keepFiles := map[string]string{"a": ".gitkeep", "b": ""}
+	render := map[string]bool{"a": true, "b": false}
+	for _, label := range []string{"a", "b"} {
+		pkg := domain.NewPackage("package-"+label, label)
+		pkg.Templates = []*domain.Template{
+			{IsFile: true, Destination: "LICENSE", Path: path, Checksum: checksums[label], Render: render[label]},
+			{IsFile: false, Destination: "logs", KeepFile: keepFiles[label]},
+		}
+		err = store.StorePackage(pkg)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, label := range []string{"a", "b"} {
+		pkg, err := store.LoadPackage(true, label)
+		if !assert.NoError(t, err) || !assert.Len(t, pkg.Templates, 2) {
+			continue
+		}
+		assert.Equal(t, checksums[label], pkg.Templates[0].Checksum, "checksum of package %s", label)
+		assert.Equal(t, keepFiles[label], pkg.Templates[1].KeepFile, "keep file of package %s", label)
+		assert.Equal(t, render[label], pkg.Templates[0].Render, "render flag of package %s", label)
+	}
+}
+
+func TestStorePackageSharedPlugins(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "proji-package-store-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	db, err := database.New("sqlite3", filepath.Join(tempDir, "proji.sqlite3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Migrate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := New(db.Connection)
+
+	// Both packages share the plugin, but only one of them runs it at a hook, with its own timeout and type.
+	path := "plugins/notify.sh"
+	types := map[string]string{"a": domain.PluginTypeExecutable, "b": ""}
+	timeouts := map[string]string{"a": "30s", "b": ""}
+	hooks := map[string]string{"a": domain.HookProjectRemoved, "b": ""}
+	for _, label := range []string{"a", "b"} {
+		pkg := domain.NewPackage("package-"+label, label)
+		pkg.Plugins = []*domain.Plugin{
+			{Path: path, ExecNumber: 1, Type: types[label], Timeout: timeouts[label], Hook: hooks[label]},
+		}
+		err = store.StorePackage(pkg)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, label := range []string{"a", "b"} {
+		pkg, err := store.LoadPackage(true, label)
+		if !assert.NoError(t, err) || !assert.Len(t, pkg.Plugins, 1) {
+			continue
+		}
+		assert.Equal(t, types[label], pkg.Plugins[0].Type, "type of package %s", label)
+		assert.Equal(t, timeouts[label], pkg.Plugins[0].Timeout, "timeout of package %s", label)
+		assert.Equal(t, hooks[label], pkg.Plugins[0].Hook, "hook of package %s", label)
+	}
+}
+
+func TestRemoveAppliedPackage(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "proji-package-store-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	db, err := database.New("sqlite3", filepath.Join(tempDir, "proji.sqlite3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Migrate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := New(db.Connection)
+
+	// The package was applied to a project.
+	pkg := domain.NewPackage("docker", "dk")
+	pkg.Templates = []*domain.Template{{IsFile: true, Destination: "Dockerfile"}}
+	err = store.StorePackage(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	project := domain.NewProject("example", filepath.Join(tempDir, "example"), nil)
+	err = db.Connection.Create(project).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Connection.Exec(
+		"INSERT INTO project_packages (project_id, package_id) VALUES (?, ?)", project.ID, pkg.ID,
+	).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !assert.NoError(t, store.RemovePackage(pkg.Label)) {
+		return
+	}
+	var applied int64
+	assert.NoError(t, db.Connection.Table("project_packages").Count(&applied).Error)
+	assert.Equal(t, int64(0), applied)
+}
@@ -0,0 +1,109 @@
+package projectservice
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/pkg/errors"
+)
+
+// ApplyPackage adds the templates and plugins of another package to an existing project. Only files that don't exist
+// yet are created and the plugins of the package run just like during the creation of a project. Afterwards, the
+// package is recorded as applied to the project together with the files that it created, which are also added to the
+// project's manifest. If recording the package fails, everything that was created is removed again. Updates of the
+// project only consider its own package, but keep the files of applied packages.
+func (ps projectService) ApplyPackage(
+	ctx context.Context, configRootPath string, project *domain.Project, pkg *domain.Package, options *domain.CreateOptions,
+) error {
+	if project.Package != nil && project.Package.Label == pkg.Label {
+		return fmt.Errorf("project %s was created with package %s", project.Path, pkg.Label)
+	}
+	for _, applied := range project.AppliedPackages {
+		if applied.Label == pkg.Label {
+			return fmt.Errorf("package %s was already applied to project %s", pkg.Label, project.Path)
+		}
+	}
+	info, err := os.Stat(project.Path)
+	if err != nil {
+		return errors.Wrap(err, "project folder")
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("project path %s is not a folder", project.Path)
+	}
+
+	applied := domain.NewProject(project.Name, project.Path, pkg)
+	return ps.createProject(ctx, configRootPath, applied, applyOptions(options), func(changes *changeLog) error {
+		files := project.Files
+		manifest := project.Manifest
+		addAppliedFiles(project, changes, applied.Files, pkg.Label)
+		err := commitAppliedPackage(changes, project)
+		if err == nil {
+			err = ps.projectStore.AddProjectPackage(project, pkg)
+		}
+		if err != nil {
+			// The project is left as it was, just like its folder after the rollback.
+			project.Files = files
+			project.Manifest = manifest
+			return errors.Wrap(err, "save project")
+		}
+		return nil
+	})
+}
+
+// addAppliedFiles adds the files that an applied package created to the files of the project. Files that the change log
+// didn't write, e.g. files of the user that existed before and were kept, aren't recorded. Files that the project
+// already records stay with the package that created them first.
+func addAppliedFiles(project *domain.Project, changes *changeLog, files []*domain.ProjectFile, label string) {
+	recorded := make(map[string]bool, len(project.Files))
+	for _, file := range project.Files {
+		recorded[file.Path] = true
+	}
+	projectFiles := make([]*domain.ProjectFile, len(project.Files), len(project.Files)+len(files))
+	copy(projectFiles, project.Files)
+	for _, file := range files {
+		if recorded[file.Path] || !changes.hasWritten(filepath.Join(project.Path, filepath.FromSlash(file.Path))) {
+			continue
+		}
+		projectFiles = append(projectFiles, &domain.ProjectFile{
+			ProjectID:      project.ID,
+			Path:           file.Path,
+			Content:        file.Content,
+			AppliedPackage: label,
+		})
+	}
+	project.Files = projectFiles
+}
+
+// commitAppliedPackage adds the files of the project to its manifest and rewrites the project's manifest file, if the
+// project has one. The manifest file is written through the given change log, so that it's restored if the package
+// can't be recorded.
+func commitAppliedPackage(changes *changeLog, project *domain.Project) error {
+	manifest := &domain.Manifest{}
+	if project.Manifest != nil {
+		*manifest = *project.Manifest
+	}
+	updateManifest(manifest, project, project.Files)
+	project.Manifest = manifest
+
+	if _, err := os.Stat(manifestPath(project.Path)); os.IsNotExist(err) {
+		return nil
+	}
+	return writeManifest(changes, project.Path, project.Manifest)
+}
+
+// applyOptions returns the options that a package is applied to an existing project with; existing files are always
+// kept and the project keeps its name, even if the package's name rules don't allow it.
+func applyOptions(options *domain.CreateOptions) *domain.CreateOptions {
+	createOptions := domain.CreateOptions{}
+	if options != nil {
+		createOptions = *options
+	}
+	createOptions.Existing = true
+	createOptions.MergeStrategy = domain.MergeStrategySkip
+	createOptions.WriteManifest = false
+	createOptions.SkipNameRules = true
+	return &createOptions
+}
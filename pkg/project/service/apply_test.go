@@ -0,0 +1,164 @@
+package projectservice
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nikoksr/proji/internal/config"
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyPackage(t *testing.T) {
+	configRootPath, err := ioutil.TempDir("", "proji-apply-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(configRootPath)
+	writeTestFiles(t, configRootPath, map[string]string{
+		"templates/readme.md":    "# readme\n",
+		"templates/dockerfile":   "FROM scratch\n",
+		"templates/dockerreadme": "# docker\n",
+	})
+
+	// The project's name doesn't follow the name rules of the applied package.
+	ownPackage := domain.NewPackage("go", "g")
+	ownPackage.Templates = []*domain.Template{{IsFile: true, Destination: "README.md", Path: "readme.md"}}
+	pkg := domain.NewPackage("docker", "dk")
+	pkg.NameRules = &domain.NameRules{Case: domain.NameCaseKebab}
+	pkg.Templates = []*domain.Template{
+		{IsFile: true, Destination: "Dockerfile", Path: "dockerfile"},
+		{IsFile: true, Destination: "README.md", Path: "dockerreadme"},
+	}
+
+	setup := func(t *testing.T) *domain.Project {
+		projectPath, err := ioutil.TempDir("", "proji-apply-test-")
+		if err != nil {
+			t.Fatal(err)
+		}
+		project := domain.NewProject("My Project", projectPath, ownPackage)
+		project.Files = []*domain.ProjectFile{{Path: "README.md", Content: []byte("# readme\n")}}
+		project.Manifest = newManifest(project, project.Files, nil)
+		manifest, err := encodeManifest(project.Manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeTestFiles(t, projectPath, map[string]string{"README.md": "# readme\n", domain.ManifestPath: string(manifest)})
+		return project
+	}
+
+	t.Run("Test apply", func(t *testing.T) {
+		project := setup(t)
+		defer os.RemoveAll(project.Path)
+		store := &projectStoreStub{}
+		ps := projectService{templateSettings: &config.Templates{}, projectStore: store}
+
+		err := ps.ApplyPackage(context.Background(), configRootPath, project, pkg, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []*domain.Project{project}, store.updated)
+
+		// Only the missing file was created and recorded for the applied package.
+		assertFileContent(t, filepath.Join(project.Path, "README.md"), "# readme\n")
+		assertFileContent(t, filepath.Join(project.Path, "Dockerfile"), "FROM scratch\n")
+		packages := make(map[string]string)
+		for _, file := range project.Files {
+			packages[file.Path] = file.AppliedPackage
+		}
+		assert.Equal(t, map[string]string{"README.md": "", "Dockerfile": "dk"}, packages)
+		manifestFiles := make(map[string]string)
+		for _, file := range project.Manifest.Files {
+			manifestFiles[file.Path] = file.Package
+		}
+		assert.Equal(t, map[string]string{"README.md": "", "Dockerfile": "dk"}, manifestFiles)
+		manifest, err := ioutil.ReadFile(manifestPath(project.Path))
+		if assert.NoError(t, err) {
+			assert.Contains(t, string(manifest), `package = "dk"`)
+		}
+
+		// Updates keep the files of applied packages.
+		project.Package = ownPackage
+		report, err := ps.UpdateProject(context.Background(), configRootPath, project, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		for _, update := range report.Files {
+			assert.NotEqual(t, "Dockerfile", update.Path)
+		}
+		assert.FileExists(t, filepath.Join(project.Path, "Dockerfile"))
+		assert.Len(t, project.Files, 2)
+	})
+
+	t.Run("Test unrecorded existing file", func(t *testing.T) {
+		project := setup(t)
+		defer os.RemoveAll(project.Path)
+		writeTestFiles(t, project.Path, map[string]string{"Dockerfile": "FROM alpine # user's own\n"})
+		ps := projectService{templateSettings: &config.Templates{}, projectStore: &projectStoreStub{}}
+
+		err := ps.ApplyPackage(context.Background(), configRootPath, project, pkg, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		// The user's file is kept and not recorded for the applied package, so it doesn't drift.
+		assertFileContent(t, filepath.Join(project.Path, "Dockerfile"), "FROM alpine # user's own\n")
+		if assert.Len(t, project.Files, 1) {
+			assert.Equal(t, "README.md", project.Files[0].Path)
+		}
+		for _, file := range project.Manifest.Files {
+			assert.NotEqual(t, "Dockerfile", file.Path)
+		}
+		report, err := ps.DiffProject(context.Background(), configRootPath, project, nil)
+		if assert.NoError(t, err) {
+			assert.Empty(t, report.Files)
+		}
+	})
+
+	t.Run("Test failing store", func(t *testing.T) {
+		project := setup(t)
+		defer os.RemoveAll(project.Path)
+		original, err := ioutil.ReadFile(manifestPath(project.Path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files, manifest := project.Files, project.Manifest
+		store := &projectStoreStub{err: errors.New("database is locked")}
+		ps := projectService{templateSettings: &config.Templates{}, projectStore: store}
+
+		err = ps.ApplyPackage(context.Background(), configRootPath, project, pkg, nil)
+		assert.Error(t, err)
+
+		// Everything is rolled back.
+		assert.NoFileExists(t, filepath.Join(project.Path, "Dockerfile"))
+		assertFileContent(t, manifestPath(project.Path), string(original))
+		assert.Equal(t, files, project.Files)
+		assert.Equal(t, manifest, project.Manifest)
+	})
+
+	t.Run("Test own and applied packages", func(t *testing.T) {
+		project := setup(t)
+		defer os.RemoveAll(project.Path)
+		ps := projectService{templateSettings: &config.Templates{}, projectStore: &projectStoreStub{}}
+
+		err := ps.ApplyPackage(context.Background(), configRootPath, project, ownPackage, nil)
+		assert.Error(t, err)
+		project.AppliedPackages = []*domain.Package{pkg}
+		err = ps.ApplyPackage(context.Background(), configRootPath, project, pkg, nil)
+		assert.Error(t, err)
+		assert.NoFileExists(t, filepath.Join(project.Path, "Dockerfile"))
+	})
+}
+
+// assertFileContent asserts that the file at the given path has the given content.
+func assertFileContent(t *testing.T, path, want string) {
+	t.Helper()
+	content, err := ioutil.ReadFile(path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, want, string(content), "content of %s", path)
+	}
+}
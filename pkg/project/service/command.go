@@ -0,0 +1,80 @@
+package projectservice
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// runCommand runs the given command and copies its stdout and stderr to the given writers. Other than exec.Cmd's own
+// copying, it stops copying output once the command exited and the context is done. Processes started by the command
+// may outlive it and keep the output pipes open, which would otherwise block proji until they finished. Writes to the
+// two writers are serialized, since they're often the same writer or write to a shared one.
+func runCommand(ctx context.Context, cmd *exec.Cmd, stdout, stderr io.Writer) error {
+	outReader, outWriter, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	errReader, errWriter, err := os.Pipe()
+	if err != nil {
+		_ = outReader.Close()
+		_ = outWriter.Close()
+		return err
+	}
+	defer outReader.Close()
+	defer errReader.Close()
+
+	cmd.Stdout = outWriter
+	cmd.Stderr = errWriter
+	err = cmd.Start()
+
+	// The command holds its own copies of the write ends now.
+	_ = outWriter.Close()
+	_ = errWriter.Close()
+	if err != nil {
+		return err
+	}
+
+	mu := &sync.Mutex{}
+	copiers := &sync.WaitGroup{}
+	copiers.Add(2)
+	go copyOutput(&lockedWriter{mu: mu, w: stdout}, outReader, copiers)
+	go copyOutput(&lockedWriter{mu: mu, w: stderr}, errReader, copiers)
+
+	err = cmd.Wait()
+	copied := make(chan struct{})
+	go func() {
+		copiers.Wait()
+		close(copied)
+	}()
+	select {
+	case <-copied:
+	case <-ctx.Done():
+		// Closing the read ends stops the copiers; nothing is written to the writers once the command returned.
+		_ = outReader.Close()
+		_ = errReader.Close()
+		<-copied
+	}
+	return err
+}
+
+// copyOutput copies everything from src to dst and marks itself as done once it's finished.
+func copyOutput(dst io.Writer, src io.Reader, done *sync.WaitGroup) {
+	defer done.Done()
+	_, _ = io.Copy(dst, src)
+}
+
+// lockedWriter is a writer that holds a mutex, which may be shared with other writers, while it writes.
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+// Write implements the io.Writer interface.
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return lw.w.Write(p)
+}
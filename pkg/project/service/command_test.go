@@ -0,0 +1,65 @@
+package projectservice
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingWriter records everything that's written to it and whether it was written to after it was closed.
+type recordingWriter struct {
+	mu            sync.Mutex
+	buffer        bytes.Buffer
+	closed        bool
+	writtenClosed bool
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		w.writtenClosed = true
+	}
+	return w.buffer.Write(p)
+}
+
+func (w *recordingWriter) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+}
+
+func TestRunCommand(t *testing.T) {
+	output := &recordingWriter{}
+	cmd := exec.Command("sh", "-c", "echo out; echo err >&2")
+	err := runCommand(context.Background(), cmd, output, output)
+	if assert.NoError(t, err) {
+		assert.Contains(t, output.buffer.String(), "out\n")
+		assert.Contains(t, output.buffer.String(), "err\n")
+	}
+}
+
+func TestRunCommandWithBackgroundProcess(t *testing.T) {
+	// The background process keeps the output pipes open after the command exited and writes once proji moved on.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	output := &recordingWriter{}
+	cmd := exec.Command("sh", "-c", "(sleep 0.5; echo late) & echo started")
+
+	started := time.Now()
+	err := runCommand(ctx, cmd, output, output)
+	output.close()
+	assert.NoError(t, err)
+	assert.Less(t, int64(time.Since(started)), int64(400*time.Millisecond), "command wasn't stopped")
+
+	time.Sleep(time.Second)
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	assert.Equal(t, "started\n", output.buffer.String())
+	assert.False(t, output.writtenClosed, "output was written after the command returned")
+}
@@ -1,125 +1,308 @@
 package projectservice
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/nikoksr/proji/pkg/domain"
-	"github.com/otiai10/copy"
 	"github.com/pkg/errors"
-	lua "github.com/yuin/gopher-lua"
 )
 
-// Create starts the creation of a project.
-func (ps projectService) CreateProject(configRootPath string, project *domain.Project) (err error) {
-	// Create the root folder of the project.
-	err = createProjectRootFolder(project.Path)
+// CreateProject creates the given project and stores it. It builds the plan for the creation first and then executes it.
+// Canceling the given context cancels the currently running plugin. If the creation fails, everything that was created
+// is removed again, unless options.KeepOnFailure is set. Storing the project is the last step of the creation, so a
+// project that can't be stored is rolled back as well. With options.Replace, storing the project replaces the project
+// that's already stored for its path. The tools that the package requires are checked first; if any of
+// them is missing, a RequirementsError is returned and nothing is created.
+//
+// If options.Existing is set, the project may be created inside of an existing folder. Files that already exist are
+// handled according to options.MergeStrategy; a rollback restores overwritten files, but keeps the existing folder.
+// Changes that plugins and steps made inside of it are kept as well and the returned error reports the partial
+// rollback.
+//
+// The working directory of proji is never changed. All files are created at absolute paths inside of the project's
+// folder and plugins run with the project's folder as their own working directory, so that several projects can be
+// created at once.
+func (ps projectService) CreateProject(ctx context.Context, configRootPath string, project *domain.Project, options *domain.CreateOptions) error {
+	return ps.createProject(ctx, configRootPath, project, options, func(*changeLog) error {
+		var err error
+		if options != nil && options.Replace {
+			err = ps.projectStore.ReplaceProject(project)
+		} else {
+			err = ps.projectStore.StoreProject(project)
+		}
+		if err != nil {
+			return errors.Wrap(err, "save project")
+		}
+		return nil
+	})
+}
+
+// createProject plans the creation of the given project, checks its requirements and executes the plan. commit is
+// called once the plan was executed successfully.
+func (ps projectService) createProject(
+	ctx context.Context, configRootPath string, project *domain.Project, options *domain.CreateOptions, commit commitFunc,
+) (err error) {
+	project.Path, err = filepath.Abs(project.Path)
 	if err != nil {
-		return errors.Wrap(err, "create base folder")
+		return errors.Wrap(err, "get absolute project path")
 	}
-
-	// Get working directory. We will be changing directories, so we need to know, where we started from.
-	workingDirectory, err := os.Getwd()
+	plan, err := newPlan(configRootPath, project, options, ps.templateSettings)
 	if err != nil {
-		return errors.Wrap(err, "get working directory")
+		return errors.Wrap(err, "plan project")
 	}
 
-	// Change directory into the new project directory and defer chdir back to old cwd
-	err = os.Chdir(project.Path)
+	// Required tools are checked and remote templates are fetched before anything is created.
+	err = checkRequirements(ctx, project.Package)
+	if err != nil {
+		return err
+	}
+	err = fetchRemoteTemplates(ctx, configRootPath, plan.Operations)
 	if err != nil {
 		return err
 	}
+	return ps.executePlan(ctx, configRootPath, plan, options, commit)
+}
+
+// commitFunc records the result of a successfully executed plan. Changes that it makes to files of the project go
+// through the given change log, so that they are rolled back if the commit fails.
+type commitFunc func(changes *changeLog) error
 
+// executePlan executes the operations of the given plan in order. Once all of them succeeded, commit is called to record
+// the result; if it fails, the creation is rolled back just like after a failed operation.
+func (ps projectService) executePlan(
+	ctx context.Context, configRootPath string, plan *domain.Plan, options *domain.CreateOptions, commit commitFunc,
+) (err error) {
+	project := plan.Project
+	if options == nil {
+		options = &domain.CreateOptions{}
+	}
+
+	// Track every file and folder that gets created, so that a failed creation can be rolled back. The rollback runs
+	// last, after the plugin log was closed. Only paths that were written through the change log are rolled back. Inside
+	// of an existing folder, whatever plugins and steps did is kept, since it can't be told apart from the user's own
+	// files; e.g. removing the objects of a commit would corrupt an existing git repository.
+	changes := &changeLog{}
+	existingFolder := false
+	var plugins *pluginRunner
 	defer func() {
-		newErr := os.Chdir(workingDirectory)
-		if newErr != nil {
-			err = newErr
+		if err == nil || options.KeepOnFailure {
+			return
+		}
+		rollbackErr := changes.rollback()
+		if rollbackErr != nil {
+			err = errors.WithMessagef(err, "failed to roll back (%v)", rollbackErr)
+			return
+		}
+		if existingFolder && plugins != nil && len(plugins.runs) > 0 {
+			err = errors.WithMessagef(err, "rolled back partially; changes of plugins and steps were kept in %s", project.Path)
 		}
 	}()
 
-	// Run plugins before creation of subfolders and files
-	pluginsRootPath := filepath.Join(configRootPath, "plugins")
-	err = preRunPlugins(pluginsRootPath, project.Package.Plugins)
-	if err != nil {
-		return err
+	// Create the root folder of the project. Existing files only need to be merged if the folder already existed.
+	mergeStrategy := ""
+	if info, statErr := os.Stat(project.Path); options.Existing && statErr == nil && info.IsDir() {
+		existingFolder = true
+		mergeStrategy = plan.MergeStrategy
+		err = checkExistingFiles(plan)
+		if err != nil {
+			return err
+		}
+	} else {
+		// The folder that the project is created in, e.g. the projects root of its package, may not exist yet.
+		err = changes.mkdirAll(filepath.Dir(project.Path))
+		if err == nil {
+			err = changes.mkdir(project.Path)
+		}
+		if err != nil {
+			return errors.Wrap(err, "create base folder")
+		}
 	}
 
-	// Create sub-folders and files
-	err = createFilesAndFolders(configRootPath, project.Package.Templates)
-	if err != nil {
-		return err
+	plugins = ps.newPluginRunner(configRootPath, project, project.Package, options.NewStatusSink)
+	if options.NonInteractive {
+		plugins.disableInput()
 	}
+	for key, value := range options.Variables {
+		plugins.context.Variables[key] = value
+	}
+	defer func() {
+		closeErr := plugins.close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
 
-	// Run plugins after all folders and files have been created
-	return postRunPlugins(pluginsRootPath, project.Package.Plugins)
-}
-
-// createProjectRootFolder tries to create the root project folder.
-func createProjectRootFolder(path string) error {
-	return os.Mkdir(path, os.ModePerm)
-}
-
-func createFilesAndFolders(configRootPath string, templates []*domain.Template) error {
-	baseTemplatesPath := filepath.Join(configRootPath, "/templates/")
-	for _, template := range templates {
-		if len(template.Path) > 0 {
-			// Copy template file or folder
-			err := copy.Copy(filepath.Join(baseTemplatesPath, template.Path), template.Destination)
-			if err != nil {
-				return err
+	// Variables set by plugins are available to all following operations.
+	var files []*domain.ProjectFile
+	for _, operation := range plan.Operations {
+		switch operation.Kind {
+		case domain.OperationCreateRoot:
+			// The root folder was created above.
+		case domain.OperationRunPlugin:
+			plugins.context.Phase = operation.Phase
+			err = plugins.run(ctx, operation.Plugin)
+		case domain.OperationRunStep:
+			plugins.context.Phase = operation.Phase
+			err = plugins.runStep(ctx, operation.Step, changes, newRenderVariables(project, plugins.context.Variables))
+		default:
+			reportConflict(options.NewStatusSink, operation)
+			variables := newRenderVariables(project, plugins.context.Variables)
+			var content []byte
+			content, err = executeFileOperation(changes, project.Path, operation, variables, mergeStrategy)
+			if err == nil && operation.Kind != domain.OperationCreateFolder {
+				path := render(operation.DestinationTemplate, variables)
+				files = setProjectFile(files, path, content, isTemplateFile(operation))
 			}
 		}
-		if template.IsFile {
-			// Create file
-			_, err := os.Create(template.Destination)
-			if err != nil {
-				return err
-			}
-		} else {
-			// Create folder
-			err := os.MkdirAll(template.Destination, os.ModePerm)
-			if err != nil {
-				return err
+		if err != nil {
+			// A kept project should still tell which plugin failed.
+			if options.KeepOnFailure && options.WriteManifest {
+				project.Variables = newRenderVariables(project, plugins.context.Variables)
+				manifestErr := writeManifest(changes, project.Path, newManifest(project, files, plugins.runs))
+				if manifestErr != nil {
+					err = errors.WithMessagef(err, "failed to write manifest (%v)", manifestErr)
+				}
 			}
+			return err
 		}
 	}
-	return nil
-}
 
-func preRunPlugins(pluginsRootPath string, plugins []*domain.Plugin) error {
-	for _, plugin := range plugins {
-		if plugin.ExecNumber >= 0 {
-			continue
-		}
-		// Plugin path is relative by default to make it shareable. We have to make it an absolute path here,
-		// so that we can execute it.
-		plugin.Path = filepath.Join(pluginsRootPath, plugin.Path)
-		err := runPlugin(plugin.Path)
+	// Record the variables, the created files and the manifest so that they are stored with the project.
+	project.Variables = newRenderVariables(project, plugins.context.Variables)
+	project.Files = files
+	project.Manifest = newManifest(project, files, plugins.runs)
+	if options.WriteManifest {
+		err = writeManifest(changes, project.Path, project.Manifest)
 		if err != nil {
-			return err
+			return errors.Wrap(err, "write manifest")
 		}
 	}
-	return nil
+	return commit(changes)
+}
+
+// writeManifest writes the given manifest into the project and records it in the given change log.
+func writeManifest(changes *changeLog, projectPath string, manifest *domain.Manifest) error {
+	content, err := encodeManifest(manifest)
+	if err != nil {
+		return err
+	}
+	return changes.writeFile(manifestPath(projectPath), content, 0o666)
 }
 
-func postRunPlugins(pluginsRootPath string, plugins []*domain.Plugin) error {
-	for _, plugin := range plugins {
-		if plugin.ExecNumber <= 0 {
-			continue
+// checkExistingFiles returns an error if the plan for a project inside of an existing folder would replace existing files
+// that its merge strategy doesn't allow to be touched.
+func checkExistingFiles(plan *domain.Plan) error {
+	if plan.MergeStrategy != domain.MergeStrategyFail {
+		return nil
+	}
+	var existing []string
+	for _, operation := range plan.Operations {
+		if operation.Exists {
+			existing = append(existing, operation.Destination)
 		}
-		// Plugin path is relative by default to make it shareable. We have to make it an absolute path here,
-		// so that we can execute it.
-		plugin.Path = filepath.Join(pluginsRootPath, plugin.Path)
-		err := runPlugin(plugin.Path)
+	}
+	if len(existing) > 0 {
+		return fmt.Errorf("%d file(s) already exist: %s", len(existing), strings.Join(existing, ", "))
+	}
+	return nil
+}
+
+// executeFileOperation creates the file or folder of the given operation inside of the project's root folder and records
+// it in the given change log. The destination is rendered with the given variables, the content of a template file only
+// by render operations. Files that existed before the creation started are handled according to the given merge
+// strategy. If the strategy is empty, they are replaced. The content that the package produced for a file is returned,
+// even if the existing file was kept.
+func executeFileOperation(
+	changes *changeLog, projectPath string, operation *domain.Operation, variables domain.Variables, mergeStrategy string,
+) ([]byte, error) {
+	destination := filepath.Join(projectPath, render(operation.DestinationTemplate, variables))
+	if operation.Kind == domain.OperationCreateFolder {
+		return nil, changes.mkdirAll(destination)
+	}
+
+	var content []byte
+	perm := os.FileMode(0o666)
+	if isTemplateFile(operation) {
+		var err error
+		content, perm, err = readTemplate(operation, variables)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
-	return nil
+
+	if mergeStrategy != "" && !changes.hasCreated(destination) {
+		if _, err := os.Stat(destination); err == nil {
+			switch mergeStrategy {
+			case domain.MergeStrategySkip:
+				return content, nil
+			case domain.MergeStrategyFail:
+				return nil, fmt.Errorf("%s already exists", destination)
+			case domain.MergeStrategyOverwrite:
+				return content, changes.writeFile(destination, content, perm)
+			}
+		}
+	}
+
+	switch operation.Kind {
+	case domain.OperationCreateFile:
+		return nil, changes.createFile(destination)
+	case domain.OperationCopyFile, domain.OperationRenderFile:
+		return content, changes.writeFile(destination, content, perm)
+	default:
+		return nil, fmt.Errorf("operation %s not supported", operation.Kind)
+	}
+}
+
+// reportConflict reports the conflict of the given operation with another operation of the plan to a new status sink.
+// Such conflicts don't stop the creation, so they would go unnoticed otherwise.
+func reportConflict(newStatusSink func() domain.StatusSink, operation *domain.Operation) {
+	if operation.Conflict == "" || newStatusSink == nil {
+		return
+	}
+	status := newStatusSink()
+	status.Write(fmt.Sprintf("conflict at %s: %s", operation.Destination, operation.Conflict))
+	status.Close()
 }
 
-func runPlugin(pluginPath string) error {
-	luaState := lua.NewState()
-	defer luaState.Close()
-	return luaState.DoFile(pluginPath)
+// isTemplateFile reports whether the given operation writes a file of a template.
+func isTemplateFile(operation *domain.Operation) bool {
+	return operation.Kind == domain.OperationCopyFile || operation.Kind == domain.OperationRenderFile
+}
+
+// readTemplate returns the content of the template file of the given operation together with the permissions of the
+// template. The content is only rendered if the operation is a render operation.
+func readTemplate(operation *domain.Operation, variables domain.Variables) ([]byte, os.FileMode, error) {
+	info, err := os.Stat(operation.Source)
+	if err != nil {
+		return nil, 0, err
+	}
+	content, err := ioutil.ReadFile(operation.Source)
+	if err != nil {
+		return nil, 0, err
+	}
+	if operation.Kind == domain.OperationRenderFile {
+		content = renderBytes(content, variables)
+	}
+	return content, info.Mode().Perm(), nil
+}
+
+// setProjectFile sets the content of the project file with the given path relative to the project's root folder. If
+// the list has no file with that path yet, a new one is appended. Existing files are only replaced if replace is set,
+// just like empty files of a package never replace files that an earlier template created.
+func setProjectFile(files []*domain.ProjectFile, path string, content []byte, replace bool) []*domain.ProjectFile {
+	path = filepath.ToSlash(filepath.Clean(path))
+	for _, file := range files {
+		if file.Path == path {
+			if replace {
+				file.Content = content
+			}
+			return files
+		}
+	}
+	return append(files, &domain.ProjectFile{Path: path, Content: content})
 }
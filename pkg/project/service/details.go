@@ -0,0 +1,32 @@
+package projectservice
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/nikoksr/proji/pkg/domain"
+)
+
+// UpdateProjectDetails validates the tags of the given project and stores its tags, description and notes.
+func (ps projectService) UpdateProjectDetails(p *domain.Project) error {
+	err := validateTags(p.Tags)
+	if err != nil {
+		return err
+	}
+	return ps.projectStore.UpdateProjectDetails(p)
+}
+
+// validateTags checks that all tags are non-empty words without whitespace or commas, so that they can be listed
+// separated by commas.
+func validateTags(tags []string) error {
+	for _, tag := range tags {
+		if tag == "" {
+			return fmt.Errorf("tag is empty")
+		}
+		if strings.ContainsRune(tag, ',') || strings.IndexFunc(tag, unicode.IsSpace) >= 0 {
+			return fmt.Errorf("tag %q must not contain whitespace or commas", tag)
+		}
+	}
+	return nil
+}
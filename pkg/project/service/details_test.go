@@ -0,0 +1,69 @@
+package projectservice
+
+import (
+	"testing"
+
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateProjectDetails(t *testing.T) {
+	tests := []struct {
+		name        string
+		tags        domain.StringList
+		description string
+		notes       string
+		wantErr     bool
+	}{
+		{
+			name:        "Test set details",
+			tags:        domain.StringList{"client:acme", "status:active"},
+			description: "Online shop of ACME",
+			notes:       "On hold",
+		},
+		{name: "Test clear details"},
+		{name: "Test invalid tag", tags: domain.StringList{"client acme"}, description: "Online shop", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &projectStoreStub{}
+			ps := projectService{projectStore: store}
+			project := domain.NewProject("shop", "/code/shop", nil)
+			project.Tags = tt.tags
+			project.Description = tt.description
+			project.Notes = tt.notes
+
+			err := ps.UpdateProjectDetails(project)
+			if tt.wantErr {
+				// Invalid details aren't stored.
+				assert.Error(t, err)
+				assert.Empty(t, store.updated)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, []*domain.Project{project}, store.updated)
+			}
+		})
+	}
+}
+
+func TestValidateTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		tags    []string
+		wantErr bool
+	}{
+		{name: "Test without tags", tags: nil, wantErr: false},
+		{name: "Test valid tags", tags: []string{"client:acme", "status/active", "archived"}, wantErr: false},
+		{name: "Test empty tag", tags: []string{"acme", ""}, wantErr: true},
+		{name: "Test tag with space", tags: []string{"client acme"}, wantErr: true},
+		{name: "Test tag with tab", tags: []string{"client\tacme"}, wantErr: true},
+		{name: "Test tag with comma", tags: []string{"acme,active"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTags(tt.tags)
+			assert.Equal(t, tt.wantErr, err != nil, "validateTags() error = %v, wantErr %v", err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package projectservice
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/nikoksr/proji/internal/diff"
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/pkg/errors"
+)
+
+// DiffProject compares the files of a project with the package's output that was recorded when the project was created
+// or last updated. Recorded files that the project lacks are missing and files whose content differs are modified.
+// With the Latest option, the project is compared with what its package produces today when it's rendered with the
+// variables stored for the project instead. Files that the package created once but no longer produces are extra then.
+func (ps projectService) DiffProject(
+	ctx context.Context, configRootPath string, project *domain.Project, options *domain.DiffOptions,
+) (*domain.DriftReport, error) {
+	if options == nil {
+		options = &domain.DiffOptions{}
+	}
+	files := recordedFiles(project)
+	if options.Latest {
+		if project.Package == nil {
+			return nil, fmt.Errorf("project %s has no package", project.Path)
+		}
+		var err error
+		files, _, err = renderPackage(ctx, configRootPath, project, ps.templateSettings)
+		if err != nil {
+			return nil, errors.Wrap(err, "render package")
+		}
+	}
+
+	report := &domain.DriftReport{Project: project.Path}
+	if project.Package != nil {
+		report.Package = project.Package.Label
+	}
+	for _, file := range files {
+		current, err := ioutil.ReadFile(filepath.Join(project.Path, filepath.FromSlash(file.path)))
+		switch {
+		case os.IsNotExist(err):
+			report.Files = append(report.Files, newFileDrift(domain.DriftMissing, file.path, file.content, nil))
+		case err != nil:
+			return nil, err
+		case !bytes.Equal(current, file.content):
+			report.Files = append(report.Files, newFileDrift(domain.DriftModified, file.path, file.content, current))
+		}
+	}
+
+	// Files that the package created once are still managed by it, even if it doesn't produce them anymore. Without the
+	// Latest option, all recorded files were compared above. Files of applied packages are never extra.
+	var extraPaths []string
+	for _, file := range project.Files {
+		if file.AppliedPackage == "" && !files.contains(file.Path) {
+			extraPaths = append(extraPaths, file.Path)
+		}
+	}
+	sort.Strings(extraPaths)
+	for _, path := range extraPaths {
+		current, err := ioutil.ReadFile(filepath.Join(project.Path, filepath.FromSlash(path)))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		report.Files = append(report.Files, newFileDrift(domain.DriftExtra, path, nil, current))
+	}
+	return report, nil
+}
+
+// recordedFiles returns the package's output that was recorded for the given project.
+func recordedFiles(project *domain.Project) renderedFiles {
+	files := make(renderedFiles, 0, len(project.Files))
+	for _, file := range project.Files {
+		files = append(files, &renderedFile{path: file.Path, content: file.Content, perm: 0o666})
+	}
+	return files
+}
+
+// newFileDrift returns the drift of a file with a unified diff from the package's output to the project's file.
+// Missing files are diffed against /dev/null.
+func newFileDrift(kind, path string, expected, current []byte) *domain.FileDrift {
+	drift := &domain.FileDrift{Path: path, Kind: kind}
+	if !isText(expected) || !isText(current) {
+		return drift
+	}
+	fromName, toName := "a/"+path, "b/"+path
+	switch kind {
+	case domain.DriftMissing:
+		toName = os.DevNull
+	case domain.DriftExtra:
+		fromName = os.DevNull
+	}
+	drift.Diff = diff.Unified(fromName, toName, string(expected), string(current), diff.DefaultContext)
+	return drift
+}
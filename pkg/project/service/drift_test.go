@@ -0,0 +1,152 @@
+package projectservice
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nikoksr/proji/internal/config"
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffProject(t *testing.T) {
+	configRootPath, err := ioutil.TempDir("", "proji-drift-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(configRootPath)
+
+	// The package changed its readme since the project was created and no longer produces old.txt. The user changed
+	// notes.txt and removed gone.txt.
+	templatesPath := filepath.Join(configRootPath, "templates")
+	projectPath := filepath.Join(configRootPath, "example")
+	writeTestFiles(t, templatesPath, map[string]string{
+		"readme.md": "# {{name}}\n\nUpdated\n",
+		"notes.txt": "notes\n",
+	})
+	writeTestFiles(t, projectPath, map[string]string{
+		"README.md": "# example\n",
+		"notes.txt": "my notes\n",
+		"old.txt":   "old\n",
+	})
+
+	pkg := domain.NewPackage("test", "tst")
+	pkg.Templates = []*domain.Template{
+		{IsFile: true, Destination: "README.md", Path: "readme.md", Render: true},
+		{IsFile: true, Destination: "notes.txt", Path: "notes.txt"},
+	}
+	project := domain.NewProject("example", projectPath, pkg)
+	project.Files = []*domain.ProjectFile{
+		{Path: "README.md", Content: []byte("# example\n")},
+		{Path: "notes.txt", Content: []byte("notes\n")},
+		{Path: "old.txt", Content: []byte("old\n")},
+		{Path: "gone.txt", Content: []byte("gone\n")},
+	}
+
+	type drift struct {
+		Kind string
+		Path string
+	}
+	tests := []struct {
+		name    string
+		options *domain.DiffOptions
+		want    []drift
+	}{
+		{
+			name: "Test recorded files",
+			want: []drift{
+				{Kind: domain.DriftModified, Path: "notes.txt"},
+				{Kind: domain.DriftMissing, Path: "gone.txt"},
+			},
+		},
+		{
+			name:    "Test latest package",
+			options: &domain.DiffOptions{Latest: true},
+			want: []drift{
+				{Kind: domain.DriftModified, Path: "README.md"},
+				{Kind: domain.DriftModified, Path: "notes.txt"},
+				{Kind: domain.DriftExtra, Path: "old.txt"},
+			},
+		},
+	}
+	ps := projectService{templateSettings: &config.Templates{}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := ps.DiffProject(context.Background(), configRootPath, project, tt.options)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, projectPath, report.Project)
+			assert.Equal(t, "tst", report.Package)
+			got := make([]drift, 0, len(report.Files))
+			for _, file := range report.Files {
+				got = append(got, drift{Kind: file.Kind, Path: file.Path})
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// Without the latest option, the package isn't needed at all.
+	project.Package = nil
+	report, err := ps.DiffProject(context.Background(), configRootPath, project, nil)
+	if assert.NoError(t, err) {
+		assert.Len(t, report.Files, 2)
+	}
+	_, err = ps.DiffProject(context.Background(), configRootPath, project, &domain.DiffOptions{Latest: true})
+	assert.Error(t, err)
+}
+
+func TestNewFileDrift(t *testing.T) {
+	tests := []struct {
+		name     string
+		kind     string
+		expected []byte
+		current  []byte
+		want     []string
+	}{
+		{
+			name:     "Test modified",
+			kind:     domain.DriftModified,
+			expected: []byte("one\ntwo\n"),
+			current:  []byte("one\nthree\n"),
+			want:     []string{"--- a/file.txt", "+++ b/file.txt", "-two", "+three"},
+		},
+		{
+			name:     "Test missing",
+			kind:     domain.DriftMissing,
+			expected: []byte("one\n"),
+			want:     []string{"--- a/file.txt", "+++ " + os.DevNull, "-one"},
+		},
+		{
+			name:    "Test extra",
+			kind:    domain.DriftExtra,
+			current: []byte("one\n"),
+			want:    []string{"--- " + os.DevNull, "+++ b/file.txt", "+one"},
+		},
+		{
+			name:     "Test binary",
+			kind:     domain.DriftModified,
+			expected: []byte("\x00one"),
+			current:  []byte("\x00two"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drift := newFileDrift(tt.kind, "file.txt", tt.expected, tt.current)
+			assert.Equal(t, tt.kind, drift.Kind)
+			assert.Equal(t, "file.txt", drift.Path)
+			if len(tt.want) == 0 {
+				assert.Empty(t, drift.Diff)
+				return
+			}
+			lines := strings.Split(drift.Diff, "\n")
+			for _, line := range tt.want {
+				assert.Contains(t, lines, line)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package projectservice
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PluginError represents an error for the case that a plugin failed, timed out or was canceled. It holds the plugin
+// and the phase it ran in, so that users know which plugin caused the error, as well as the plugin's complete output
+// and the path of the plugin log.
+type PluginError struct {
+	Path    string
+	Phase   string
+	Timeout time.Duration
+	Output  string
+	LogPath string
+	Err     error
+}
+
+func (e *PluginError) Error() string {
+	switch e.Err {
+	case context.DeadlineExceeded:
+		return fmt.Sprintf("plugin %s (%s) timed out after %s", e.Path, e.Phase, e.Timeout)
+	case context.Canceled:
+		return fmt.Sprintf("plugin %s (%s) was canceled", e.Path, e.Phase)
+	default:
+		return fmt.Sprintf("plugin %s (%s) failed: %v", e.Path, e.Phase, e.Err)
+	}
+}
+
+func (e *PluginError) Unwrap() error {
+	return e.Err
+}
+
+// StepError represents an error for the case that a built-in step failed, timed out or was canceled. Like a
+// PluginError, it holds the step's complete output and the path of the plugin log.
+type StepError struct {
+	Name    string
+	Phase   string
+	Timeout time.Duration
+	Output  string
+	LogPath string
+	Err     error
+}
+
+func (e *StepError) Error() string {
+	switch e.Err {
+	case context.DeadlineExceeded:
+		return fmt.Sprintf("step %s (%s) timed out after %s", e.Name, e.Phase, e.Timeout)
+	case context.Canceled:
+		return fmt.Sprintf("step %s (%s) was canceled", e.Name, e.Phase)
+	default:
+		return fmt.Sprintf("step %s (%s) failed: %v", e.Name, e.Phase, e.Err)
+	}
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// RequirementsError represents an error for the case that tools that a package requires are missing or installed in a
+// version that doesn't satisfy the package. It holds the checks of all unmet requirements. Requirements are checked
+// before anything is created, so nothing has to be rolled back.
+type RequirementsError struct {
+	Package string
+	Failed  []*RequirementCheck
+}
+
+func (e *RequirementsError) Error() string {
+	requirements := make([]string, 0, len(e.Failed))
+	for _, check := range e.Failed {
+		requirements = append(requirements, check.Requirement)
+	}
+	return fmt.Sprintf("%d requirement(s) of package %s not met: %s", len(e.Failed), e.Package, strings.Join(requirements, ", "))
+}
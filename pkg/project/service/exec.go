@@ -0,0 +1,36 @@
+package projectservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/pkg/errors"
+)
+
+// runExecutablePlugin runs an executable plugin. The plugin receives the plugin context as JSON on stdin and as
+// environment variables. If the last line the plugin writes to stdout is a JSON object, it is read as the plugin's
+// result. All output of the plugin is written to output. The plugin runs inside of its working directory and gets
+// killed once the context is done.
+func runExecutablePlugin(ctx context.Context, pluginPath string, pc *pluginContext, output io.Writer) (*pluginResult, error) {
+	input, err := json.Marshal(pc)
+	if err != nil {
+		return nil, errors.Wrap(err, "encode plugin context")
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, pluginPath)
+	cmd.Dir = pc.workingDirectory()
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Env = append(os.Environ(), pc.environment()...)
+
+	err = runCommand(ctx, cmd, io.MultiWriter(&stdout, output), output)
+	if err != nil {
+		return nil, err
+	}
+	result, _ := parsePluginOutput(stdout.Bytes())
+	return result, nil
+}
@@ -0,0 +1,100 @@
+package projectservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunExecutablePlugin(t *testing.T) {
+	projectPath, err := ioutil.TempDir("", "proji-exec-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(projectPath)
+
+	// The plugin records its stdin and its environment inside of its working directory, which is the project's folder.
+	pluginPath := filepath.Join(projectPath, "plugin.sh")
+	writeTestFiles(t, projectPath, map[string]string{
+		"plugin.sh": `#!/bin/sh
+cat > stdin.json
+env | grep -E '^PROJI_(PHASE|CONFIG_PATH|PROJECT_|PACKAGE_|VAR_)' | sort > env.txt
+echo "working on $PROJI_PROJECT_NAME" >&2
+echo "done"
+echo "$RESULT"
+`,
+	})
+	err = os.Chmod(pluginPath, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pc := &pluginContext{
+		Phase:      phasePreRun,
+		ConfigPath: "/home/user/.config/proji",
+		Project:    pluginProject{Name: "example", Path: projectPath},
+		Package:    pluginPackage{Name: "go", Label: "g"},
+		Variables:  map[string]string{"app.name": "example-app"},
+	}
+
+	tests := []struct {
+		name   string
+		result string
+		want   *pluginResult
+	}{
+		{
+			name:   "Test result",
+			result: `{"variables": {"go_version": "1.15"}}`,
+			want:   &pluginResult{Variables: map[string]string{"go_version": "1.15"}},
+		},
+		{name: "Test invalid result", result: `{"variables": `},
+		{name: "Test empty result"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := os.Setenv("RESULT", tt.result)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Unsetenv("RESULT")
+
+			var output bytes.Buffer
+			result, err := runExecutablePlugin(context.Background(), pluginPath, pc, &output)
+			if !assert.NoError(t, err, output.String()) {
+				return
+			}
+			assert.Equal(t, tt.want, result)
+			assert.Contains(t, output.String(), "working on example\n")
+			assert.Contains(t, output.String(), "done\n")
+
+			// The plugin context is passed as JSON on stdin.
+			stdin, err := ioutil.ReadFile(filepath.Join(projectPath, "stdin.json"))
+			if assert.NoError(t, err) {
+				var got pluginContext
+				assert.NoError(t, json.Unmarshal(stdin, &got))
+				assert.Equal(t, *pc, got)
+			}
+
+			// And as environment variables.
+			env, err := ioutil.ReadFile(filepath.Join(projectPath, "env.txt"))
+			if assert.NoError(t, err) {
+				assert.Equal(t, []string{
+					"PROJI_CONFIG_PATH=/home/user/.config/proji",
+					"PROJI_PACKAGE_LABEL=g",
+					"PROJI_PACKAGE_NAME=go",
+					"PROJI_PHASE=pre",
+					"PROJI_PROJECT_NAME=example",
+					"PROJI_PROJECT_PATH=" + projectPath,
+					"PROJI_VAR_APP_NAME=example-app",
+				}, strings.Split(strings.TrimSpace(string(env)), "\n"))
+			}
+		})
+	}
+}
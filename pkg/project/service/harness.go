@@ -0,0 +1,227 @@
+package projectservice
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultTestProjectName = "example"
+	testPackageName        = "plugin-test"
+	testPackageLabel       = "test"
+)
+
+// TestPlugin runs a single plugin inside a temporary project folder with a fake project context and reports what the
+// plugin did. Commands that a lua plugin starts with os.execute or io.popen are only recorded, unless
+// options.RunCommands is set; io.popen fails for commands that aren't run. Commands that an executable plugin starts
+// can't be recorded, since they aren't started by proji. A failing plugin doesn't cause an error; its error is part of
+// the report.
+func (ps projectService) TestPlugin(
+	ctx context.Context, configRootPath string, plugin *domain.Plugin, options *domain.PluginTestOptions,
+) (report *domain.PluginTestReport, err error) {
+	if options == nil {
+		options = &domain.PluginTestOptions{}
+	}
+	phase := options.Phase
+	if phase == "" {
+		phase = phasePostRun
+	}
+	if !isTestPhase(phase) {
+		return nil, fmt.Errorf("phase %s not supported", phase)
+	}
+	projectName := options.ProjectName
+	if projectName == "" {
+		projectName = defaultTestProjectName
+	}
+
+	// Create the temporary project
+	tempDir, err := ioutil.TempDir("", "proji-plugin-test-")
+	if err != nil {
+		return nil, errors.Wrap(err, "create temporary folder")
+	}
+	projectPath := filepath.Join(tempDir, projectName)
+	if !options.KeepProject {
+		defer func() {
+			removeErr := os.RemoveAll(tempDir)
+			if err == nil && removeErr != nil {
+				err = errors.Wrap(removeErr, "remove temporary folder")
+			}
+		}()
+	}
+	err = os.Mkdir(projectPath, os.ModePerm)
+	if err != nil {
+		return nil, errors.Wrap(err, "create temporary project")
+	}
+
+	pkg := domain.NewPackage(testPackageName, testPackageLabel)
+	project := domain.NewProject(projectName, projectPath, pkg)
+	commands := &commandRecorder{runCommands: options.RunCommands}
+
+	plugins := ps.newPluginRunner(configRootPath, project, pkg, nil)
+	plugins.logName = "plugin-test-" + filepath.Base(plugin.Path)
+	plugins.executeCommand = commands.execute
+	plugins.popenCommand = commands.popen
+	plugins.context.Phase = phase
+	for key, value := range options.Variables {
+		plugins.context.Variables[key] = value
+	}
+	defer func() {
+		closeErr := plugins.close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	// Run the plugin and compare the project folder before and after the run.
+	before, err := snapshotFiles(projectPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "scan temporary project")
+	}
+	started := time.Now()
+	runErr := plugins.run(ctx, plugin)
+	duration := time.Since(started)
+	after, err := snapshotFiles(projectPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "scan temporary project")
+	}
+
+	report = &domain.PluginTestReport{
+		ProjectPath: projectPath,
+		Phase:       phase,
+		Duration:    duration,
+		Output:      plugins.lastOutput,
+		Commands:    commands.list(),
+		Variables:   make(map[string]string),
+		Err:         runErr,
+	}
+	if plugins.log != nil {
+		report.LogPath = plugins.log.path
+	}
+	report.CreatedFiles, report.ChangedFiles, report.RemovedFiles = compareSnapshots(before, after)
+	for key, value := range plugins.context.Variables {
+		if options.Variables[key] != value {
+			report.Variables[key] = value
+		}
+	}
+	return report, nil
+}
+
+// isTestPhase reports whether a plugin can be tested in the given phase.
+func isTestPhase(phase string) bool {
+	if phase == phasePreRun || phase == phasePostRun {
+		return true
+	}
+	for _, hook := range domain.Hooks {
+		if phase == hook {
+			return true
+		}
+	}
+	return false
+}
+
+// commandRecorder records the commands that a lua plugin tries to run with os.execute or io.popen and optionally runs
+// them.
+type commandRecorder struct {
+	mu          sync.Mutex
+	runCommands bool
+	commands    []string
+}
+
+func (cr *commandRecorder) execute(ctx context.Context, dir, command string, output io.Writer) error {
+	if !cr.popen(command) {
+		return nil
+	}
+	return executeShellCommand(ctx, dir, command, output)
+}
+
+// popen records the given command and reports whether it should be run.
+func (cr *commandRecorder) popen(command string) bool {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	cr.commands = append(cr.commands, command)
+	return cr.runCommands
+}
+
+func (cr *commandRecorder) list() []string {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	return append([]string(nil), cr.commands...)
+}
+
+// snapshotFiles returns a checksum of every file and folder below the given root, keyed by their relative path.
+// Folders are marked by a trailing slash.
+func snapshotFiles(root string) (map[string]string, error) {
+	snapshot := make(map[string]string)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			snapshot[filepath.ToSlash(relPath)+"/"] = ""
+			return nil
+		}
+		checksum, err := fileChecksum(path)
+		if err != nil {
+			return err
+		}
+		snapshot[filepath.ToSlash(relPath)] = checksum
+		return nil
+	})
+	return snapshot, err
+}
+
+// fileChecksum returns the sha256 checksum of the given file.
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+// compareSnapshots returns the sorted paths that were created, changed and removed between two snapshots.
+func compareSnapshots(before, after map[string]string) (created, changed, removed []string) {
+	for path, checksum := range after {
+		oldChecksum, ok := before[path]
+		switch {
+		case !ok:
+			created = append(created, path)
+		case oldChecksum != checksum:
+			changed = append(changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			removed = append(removed, path)
+		}
+	}
+	sort.Strings(created)
+	sort.Strings(changed)
+	sort.Strings(removed)
+	return created, changed, removed
+}
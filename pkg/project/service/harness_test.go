@@ -0,0 +1,69 @@
+package projectservice
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTestPlugin(t *testing.T) {
+	configRootPath, err := ioutil.TempDir("", "proji-harness-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(configRootPath)
+
+	pluginPath := filepath.Join(configRootPath, "plugin.lua")
+	_ = ioutil.WriteFile(pluginPath, []byte(`
+local file = assert(io.open("created.txt", "w"))
+file:write(proji.variables.greeting)
+file:close()
+os.execute("echo executed > executed.txt")
+local handle, err = io.popen("echo popened")
+if handle then
+  proji.variables.popen = handle:read("*l")
+  handle:close()
+else
+  proji.variables.popen = "not run"
+end
+`), 0o600)
+
+	tests := []struct {
+		name        string
+		options     *domain.PluginTestOptions
+		wantCreated []string
+		wantPopen   string
+	}{
+		{
+			name:        "Test recorded commands",
+			options:     &domain.PluginTestOptions{Variables: map[string]string{"greeting": "hello"}},
+			wantCreated: []string{"created.txt"},
+			wantPopen:   "not run",
+		},
+		{
+			name:        "Test run commands",
+			options:     &domain.PluginTestOptions{Variables: map[string]string{"greeting": "hello"}, RunCommands: true},
+			wantCreated: []string{"created.txt", "executed.txt"},
+			wantPopen:   "popened",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := projectService{}
+			report, err := ps.TestPlugin(context.Background(), configRootPath, &domain.Plugin{Path: pluginPath}, tt.options)
+			if !assert.NoError(t, err) || !assert.NoError(t, report.Err, report.Output) {
+				return
+			}
+
+			assert.Equal(t, []string{"echo executed > executed.txt", "echo popened"}, report.Commands)
+			assert.Equal(t, tt.wantCreated, report.CreatedFiles)
+			assert.Equal(t, map[string]string{"popen": tt.wantPopen}, report.Variables)
+			assert.NoDirExists(t, report.ProjectPath)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package projectservice
+
+import (
+	"context"
+
+	"github.com/nikoksr/proji/pkg/domain"
+)
+
+// RunProjectHook runs all plugins of the project's package that registered for the given hook. Projects without a
+// package, e.g. projects that were added instead of created or whose package was removed, have no hooks.
+func (ps projectService) RunProjectHook(
+	ctx context.Context, configRootPath, hook string, project *domain.Project, options *domain.HookOptions,
+) (err error) {
+	if project.Package == nil {
+		return nil
+	}
+	plugins := ps.newHookRunner(configRootPath, project, project.Package, options)
+	defer func() {
+		closeErr := plugins.close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+	return plugins.runHook(ctx, hook, project.Package.Plugins)
+}
+
+// RunPackageHook runs all plugins of the given package that registered for the given hook.
+func (ps projectService) RunPackageHook(
+	ctx context.Context, configRootPath, hook string, pkg *domain.Package, options *domain.HookOptions,
+) (err error) {
+	plugins := ps.newHookRunner(configRootPath, nil, pkg, options)
+	defer func() {
+		closeErr := plugins.close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+	return plugins.runHook(ctx, hook, pkg.Plugins)
+}
+
+// newHookRunner returns a plugin runner whose plugin context holds the information about the hook's event.
+func (ps projectService) newHookRunner(
+	configRootPath string, project *domain.Project, pkg *domain.Package, options *domain.HookOptions,
+) *pluginRunner {
+	if options == nil {
+		return ps.newPluginRunner(configRootPath, project, pkg, nil)
+	}
+	plugins := ps.newPluginRunner(configRootPath, project, pkg, options.NewStatusSink)
+	plugins.context.Project.PreviousPath = options.PreviousPath
+	if options.NonInteractive {
+		plugins.disableInput()
+	}
+	if options.Err != nil {
+		plugins.context.Error = options.Err.Error()
+	}
+	return plugins
+}
@@ -0,0 +1,183 @@
+package projectservice
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nikoksr/proji/internal/config"
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunHook(t *testing.T) {
+	configRootPath, err := ioutil.TempDir("", "proji-hook-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(configRootPath)
+
+	// The plugin records its working directory and the hook's context in the config folder.
+	writeTestFiles(t, filepath.Join(configRootPath, "plugins"), map[string]string{
+		"record.sh": `#!/bin/sh
+{
+  echo "dir=$(pwd)"
+  env | grep -E '^PROJI_(PHASE|PROJECT_|PACKAGE_LABEL|ERROR)' | sort
+} > "$PROJI_CONFIG_PATH/$PROJI_PHASE.txt"
+`,
+		"fail.sh":  "#!/bin/sh\nexit 3\n",
+		"sleep.sh": "#!/bin/sh\nexec sleep 5\n",
+	})
+	for _, name := range []string{"record.sh", "fail.sh", "sleep.sh"} {
+		err = os.Chmod(filepath.Join(configRootPath, "plugins", name), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	projectPath := filepath.Join(configRootPath, "example")
+	err = os.Mkdir(projectPath, os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	workingDirectory, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newPackage := func(plugins ...*domain.Plugin) *domain.Package {
+		pkg := domain.NewPackage("go", "g")
+		pkg.Plugins = plugins
+		return pkg
+	}
+	readRecord := func(t *testing.T, hook string) []string {
+		t.Helper()
+		content, err := ioutil.ReadFile(filepath.Join(configRootPath, hook+".txt"))
+		if !assert.NoError(t, err, "plugin for %s didn't run", hook) {
+			return nil
+		}
+		return strings.Split(strings.TrimSpace(string(content)), "\n")
+	}
+	ps := projectService{pluginSettings: &config.Plugins{}}
+
+	t.Run("Test project hook", func(t *testing.T) {
+		pkg := newPackage(
+			&domain.Plugin{Path: "record.sh", Hook: domain.HookProjectMoved},
+			&domain.Plugin{Path: "fail.sh", Hook: domain.HookProjectRemoved},
+			&domain.Plugin{Path: "fail.sh"},
+		)
+		project := domain.NewProject("example", projectPath, pkg)
+		options := &domain.HookOptions{PreviousPath: "/tmp/old-example"}
+
+		err := ps.RunProjectHook(context.Background(), configRootPath, domain.HookProjectMoved, project, options)
+		if !assert.NoError(t, err) {
+			return
+		}
+		// The plugin runs inside of the project's folder; plugins of other hooks don't run.
+		assert.Equal(t, []string{
+			"dir=" + projectPath,
+			"PROJI_PACKAGE_LABEL=g",
+			"PROJI_PHASE=" + domain.HookProjectMoved,
+			"PROJI_PROJECT_NAME=example",
+			"PROJI_PROJECT_PATH=" + projectPath,
+			"PROJI_PROJECT_PREVIOUS_PATH=/tmp/old-example",
+		}, readRecord(t, domain.HookProjectMoved))
+	})
+
+	t.Run("Test project hook without project folder", func(t *testing.T) {
+		pkg := newPackage(&domain.Plugin{Path: "record.sh", Hook: domain.HookProjectRemoved})
+		removedPath := filepath.Join(configRootPath, "removed")
+		project := domain.NewProject("removed", removedPath, pkg)
+
+		err := ps.RunProjectHook(context.Background(), configRootPath, domain.HookProjectRemoved, project, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		// Plugins inherit proji's working directory if the project's folder is gone.
+		assert.Equal(t, []string{
+			"dir=" + workingDirectory,
+			"PROJI_PACKAGE_LABEL=g",
+			"PROJI_PHASE=" + domain.HookProjectRemoved,
+			"PROJI_PROJECT_NAME=removed",
+			"PROJI_PROJECT_PATH=" + removedPath,
+		}, readRecord(t, domain.HookProjectRemoved))
+	})
+
+	t.Run("Test project without package", func(t *testing.T) {
+		project := domain.NewProject("example", projectPath, nil)
+		err := ps.RunProjectHook(context.Background(), configRootPath, domain.HookProjectCleaned, project, nil)
+		assert.NoError(t, err)
+		assert.NoFileExists(t, filepath.Join(configRootPath, domain.HookProjectCleaned+".txt"))
+	})
+
+	t.Run("Test package hook", func(t *testing.T) {
+		pkg := newPackage(&domain.Plugin{Path: "record.sh", Hook: domain.HookPackageImported})
+		options := &domain.HookOptions{Err: errors.New("import failed")}
+
+		err := ps.RunPackageHook(context.Background(), configRootPath, domain.HookPackageImported, pkg, options)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []string{
+			"dir=" + workingDirectory,
+			"PROJI_ERROR=import failed",
+			"PROJI_PACKAGE_LABEL=g",
+			"PROJI_PHASE=" + domain.HookPackageImported,
+			"PROJI_PROJECT_NAME=",
+			"PROJI_PROJECT_PATH=",
+		}, readRecord(t, domain.HookPackageImported))
+	})
+
+	tests := []struct {
+		name        string
+		plugin      *domain.Plugin
+		cancel      bool
+		wantErr     error
+		wantMessage string
+	}{
+		{
+			name:        "Test failing plugin",
+			plugin:      &domain.Plugin{Path: "fail.sh", Hook: domain.HookProjectCleaned},
+			wantMessage: "plugin fail.sh (project-cleaned hook) failed: exit status 3",
+		},
+		{
+			name:        "Test timed out plugin",
+			plugin:      &domain.Plugin{Path: "sleep.sh", Hook: domain.HookProjectCleaned, Timeout: "100ms"},
+			wantErr:     context.DeadlineExceeded,
+			wantMessage: "plugin sleep.sh (project-cleaned hook) timed out after 100ms",
+		},
+		{
+			name:        "Test canceled plugin",
+			plugin:      &domain.Plugin{Path: "sleep.sh", Hook: domain.HookProjectCleaned},
+			cancel:      true,
+			wantErr:     context.Canceled,
+			wantMessage: "plugin sleep.sh (project-cleaned hook) was canceled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := domain.NewProject("example", projectPath, newPackage(tt.plugin))
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				time.AfterFunc(100*time.Millisecond, cancel)
+			}
+
+			started := time.Now()
+			err := ps.RunProjectHook(ctx, configRootPath, domain.HookProjectCleaned, project, nil)
+			assert.Less(t, int64(time.Since(started)), int64(3*time.Second), "plugin wasn't stopped")
+			var pluginErr *PluginError
+			if !assert.True(t, errors.As(err, &pluginErr), "error %v is no plugin error", err) {
+				return
+			}
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "error %v is no %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.wantMessage, err.Error())
+		})
+	}
+}
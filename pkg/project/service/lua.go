@@ -0,0 +1,437 @@
+package projectservice
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/nikoksr/proji/internal/static"
+	"github.com/pkg/errors"
+	lua "github.com/yuin/gopher-lua"
+)
+
+const (
+	luaWriterClass    = "proji.writer"
+	luaStdLibraryName = "proji.std"
+)
+
+// runLuaPlugin runs a lua plugin. The plugin context is exposed to the plugin through the global table proji.
+//
+// Everything the plugin prints, including the output of commands started with os.execute, is written to output.
+// Commands started with os.execute are run by the given command executor. Commands started with io.popen are only run
+// if the given command filter is nil or allows them. Unless the plugin is interactive, io.stdin and io.read read an empty
+// input instead of proji's stdin. Calling os.exit ends the plugin instead of proji; a non-zero exit status is treated as
+// a plugin failure.
+//
+// Plugins can require lua modules from the lib folder of proji's plugins folder and proji's bundled standard library,
+// the module proji.std.
+//
+// Relative paths that the plugin passes to lua's file functions and the commands it runs are resolved against the
+// plugin's working directory, without changing the working directory of proji itself.
+//
+// The lua state runs in its own goroutine so that a plugin which blocks outside of the lua VM, e.g. while reading from
+// stdin, doesn't block proji once the context is done.
+func runLuaPlugin(
+	ctx context.Context, pluginPath string, pc *pluginContext, output io.Writer, execute commandExecutor, popen commandFilter,
+	interactive bool,
+) (*pluginResult, error) {
+	dir := pc.workingDirectory()
+	luaState := lua.NewState()
+	luaState.SetContext(ctx)
+	contextTable := newLuaContextTable(luaState, pc)
+	luaState.SetGlobal("proji", contextTable)
+	setupLuaPackage(luaState, pc.ConfigPath)
+	luaState.PreloadModule(luaStdLibraryName, newLuaStdLibraryLoader(dir))
+
+	exit := &luaExitStatus{}
+	redirectLuaOutput(luaState, output)
+	if !interactive {
+		err := detachLuaInput(luaState)
+		if err != nil {
+			luaState.Close()
+			return nil, errors.Wrap(err, "detach input")
+		}
+	}
+	setLuaWorkingDirectory(luaState, dir, popen)
+	osTable := luaState.GetGlobal("os")
+	luaState.SetField(osTable, "execute", luaState.NewFunction(luaExecute(ctx, dir, output, execute)))
+	luaState.SetField(osTable, "exit", luaState.NewFunction(luaExit(exit)))
+
+	// Variables that the plugin sets in proji.variables are read back once it finished.
+	var result *pluginResult
+	done := make(chan error, 1)
+	go func() {
+		defer luaState.Close()
+		err := luaState.DoFile(pluginPath)
+		result = readLuaResult(contextTable)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		switch {
+		case !exit.called && err != nil:
+			return nil, err
+		case exit.status != 0:
+			return nil, &luaExitError{status: exit.status}
+		default:
+			return result, nil
+		}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// newLuaContextTable converts the plugin context into a lua table.
+func newLuaContextTable(luaState *lua.LState, pc *pluginContext) *lua.LTable {
+	project := luaState.NewTable()
+	project.RawSetString("name", lua.LString(pc.Project.Name))
+	project.RawSetString("path", lua.LString(pc.Project.Path))
+	if pc.Project.PreviousPath != "" {
+		project.RawSetString("previous_path", lua.LString(pc.Project.PreviousPath))
+	}
+
+	pkg := luaState.NewTable()
+	pkg.RawSetString("name", lua.LString(pc.Package.Name))
+	pkg.RawSetString("label", lua.LString(pc.Package.Label))
+
+	variables := luaState.NewTable()
+	for key, value := range pc.Variables {
+		variables.RawSetString(key, lua.LString(value))
+	}
+
+	context := luaState.NewTable()
+	context.RawSetString("phase", lua.LString(pc.Phase))
+	context.RawSetString("config_path", lua.LString(pc.ConfigPath))
+	context.RawSetString("project", project)
+	context.RawSetString("package", pkg)
+	context.RawSetString("variables", variables)
+	if pc.Error != "" {
+		context.RawSetString("error", lua.LString(pc.Error))
+	}
+	return context
+}
+
+// setupLuaPackage adds the lib folder of proji's plugins folder to the paths that lua searches for modules. It also sets
+// package.config like lua 5.2 does, so that plugins can detect the path separator of the system.
+func setupLuaPackage(luaState *lua.LState, configRootPath string) {
+	libPath := filepath.Join(configRootPath, "plugins", "lib")
+	packageTable := luaState.GetGlobal("package")
+	luaState.SetField(packageTable, "config", lua.LString(string(filepath.Separator)+"\n;\n?\n!\n-\n"))
+	searchPath := filepath.Join(libPath, "?.lua") + ";" + filepath.Join(libPath, "?", "init.lua")
+	if currentPath := luaState.GetField(packageTable, "path").String(); currentPath != "" {
+		searchPath += ";" + currentPath
+	}
+	luaState.SetField(packageTable, "path", lua.LString(searchPath))
+}
+
+// newLuaStdLibraryLoader returns a loader for proji's bundled lua standard library. Helpers that lua can't implement
+// portably are added as Go functions. Relative paths are resolved against the given working directory.
+func newLuaStdLibraryLoader(dir string) lua.LGFunction {
+	return func(luaState *lua.LState) int {
+		module, err := luaState.LoadString(static.LuaStandardLibrary)
+		if err != nil {
+			luaState.RaiseError("load %s: %v", luaStdLibraryName, err)
+			return 0
+		}
+		luaState.Push(module)
+		luaState.Call(0, 1)
+
+		std := luaState.CheckTable(-1)
+		luaState.SetFuncs(std, map[string]lua.LGFunction{
+			"exists":  luaStat(dir, func(info os.FileInfo) bool { return true }),
+			"is_dir":  luaStat(dir, os.FileInfo.IsDir),
+			"is_file": luaStat(dir, func(info os.FileInfo) bool { return info.Mode().IsRegular() }),
+		})
+		return 1
+	}
+}
+
+// luaStat returns a lua function which reports whether the file at the given path exists and satisfies the given
+// check.
+func luaStat(dir string, check func(info os.FileInfo) bool) lua.LGFunction {
+	return func(luaState *lua.LState) int {
+		info, err := os.Stat(resolvePath(dir, luaState.CheckString(1)))
+		luaState.Push(lua.LBool(err == nil && check(info)))
+		return 1
+	}
+}
+
+// setLuaWorkingDirectory makes lua's file functions resolve relative paths against the given working directory and runs
+// io.popen's commands inside of it. Since the working directory of a process is shared by all of its goroutines, every
+// lua state keeps its own instead. If the given command filter rejects a command of io.popen, io.popen fails like it
+// does for commands that can't be started.
+func setLuaWorkingDirectory(luaState *lua.LState, dir string, filter commandFilter) {
+	pathArguments := map[string]map[string][]int{
+		"io": {"open": {1}, "lines": {1}, "input": {1}, "output": {1}},
+		"os": {"remove": {1}, "rename": {1, 2}},
+		"_G": {"dofile": {1}, "loadfile": {1}},
+	}
+	for tableName, functions := range pathArguments {
+		if dir == "" {
+			break
+		}
+		table, ok := luaState.GetGlobal(tableName).(*lua.LTable)
+		if !ok {
+			continue
+		}
+		for name, positions := range functions {
+			original := luaState.GetField(table, name)
+			if original == lua.LNil {
+				continue
+			}
+			luaState.SetField(table, name, luaState.NewFunction(luaResolvePaths(dir, original, positions)))
+		}
+	}
+
+	ioTable := luaState.GetGlobal("io")
+	popen := luaState.GetField(ioTable, "popen")
+	if popen != lua.LNil {
+		luaState.SetField(ioTable, "popen", luaState.NewFunction(func(luaState *lua.LState) int {
+			command := luaState.CheckString(1)
+			if filter != nil && !filter(command) {
+				luaState.Push(lua.LNil)
+				luaState.Push(lua.LString(fmt.Sprintf("%s: command not run", command)))
+				return 2
+			}
+			if dir != "" {
+				luaState.Replace(1, lua.LString(shellChangeDirectory(dir, command)))
+			}
+			return luaCallOriginal(luaState, popen)
+		}))
+	}
+}
+
+// luaResolvePaths returns a lua function which resolves the relative paths at the given argument positions against
+// the given working directory and then calls the original function.
+func luaResolvePaths(dir string, original lua.LValue, positions []int) lua.LGFunction {
+	return func(luaState *lua.LState) int {
+		for _, position := range positions {
+			if path, ok := luaState.Get(position).(lua.LString); ok {
+				luaState.Replace(position, lua.LString(resolvePath(dir, string(path))))
+			}
+		}
+		return luaCallOriginal(luaState, original)
+	}
+}
+
+// luaCallOriginal calls the given function with all arguments on the stack and returns all of its results.
+func luaCallOriginal(luaState *lua.LState, original lua.LValue) int {
+	top := luaState.GetTop()
+	luaState.Insert(original, 1)
+	luaState.Call(top, lua.MultRet)
+	return luaState.GetTop()
+}
+
+// resolvePath joins relative paths with the given working directory. Absolute paths and paths without a working
+// directory are returned unchanged.
+func resolvePath(dir, path string) string {
+	if dir == "" || path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// readLuaResult reads the variables of the proji table back after a plugin ran. Values that aren't strings, numbers or
+// booleans are ignored.
+func readLuaResult(contextTable *lua.LTable) *pluginResult {
+	variables, ok := contextTable.RawGetString("variables").(*lua.LTable)
+	if !ok {
+		return nil
+	}
+	result := &pluginResult{Variables: make(map[string]string)}
+	variables.ForEach(func(key, value lua.LValue) {
+		switch value.(type) {
+		case lua.LString, lua.LNumber, lua.LBool:
+			result.Variables[key.String()] = value.String()
+		}
+	})
+	return result
+}
+
+// redirectLuaOutput replaces print, io.write, io.stdout and io.stderr with versions that write to the given writer.
+func redirectLuaOutput(luaState *lua.LState, output io.Writer) {
+	writerMethods := luaState.NewTable()
+	luaState.SetFuncs(writerMethods, map[string]lua.LGFunction{
+		"write":   luaWriterWrite,
+		"flush":   luaWriterNoop,
+		"close":   luaWriterNoop,
+		"setvbuf": luaWriterNoop,
+	})
+	writerMetatable := luaState.NewTypeMetatable(luaWriterClass)
+	luaState.SetField(writerMetatable, "__index", writerMethods)
+
+	writer := luaState.NewUserData()
+	writer.Value = output
+	luaState.SetMetatable(writer, writerMetatable)
+
+	ioTable := luaState.GetGlobal("io")
+	luaState.SetField(ioTable, "stdout", writer)
+	luaState.SetField(ioTable, "stderr", writer)
+	luaState.SetField(ioTable, "write", luaState.NewFunction(func(luaState *lua.LState) int {
+		luaState.Insert(writer, 1)
+		return luaWriterWrite(luaState)
+	}))
+	luaState.SetGlobal("print", luaState.NewFunction(func(luaState *lua.LState) int {
+		top := luaState.GetTop()
+		for i := 1; i <= top; i++ {
+			_, _ = fmt.Fprint(output, luaState.ToStringMeta(luaState.Get(i)).String())
+			if i != top {
+				_, _ = fmt.Fprint(output, "\t")
+			}
+		}
+		_, _ = fmt.Fprintln(output)
+		return 0
+	}))
+}
+
+// luaWriterWrite implements the write method of redirected lua files.
+func luaWriterWrite(luaState *lua.LState) int {
+	writer := luaState.CheckUserData(1)
+	output, ok := writer.Value.(io.Writer)
+	if !ok {
+		luaState.ArgError(1, "file expected")
+		return 0
+	}
+	for i := 2; i <= luaState.GetTop(); i++ {
+		_, err := io.WriteString(output, luaState.CheckString(i))
+		if err != nil {
+			luaState.Push(lua.LNil)
+			luaState.Push(lua.LString(err.Error()))
+			return 2
+		}
+	}
+	luaState.Push(writer)
+	return 1
+}
+
+// detachLuaInput replaces io.stdin and the default input of io.read with an empty file, so that the plugin
+// doesn't read proji's stdin.
+func detachLuaInput(luaState *lua.LState) error {
+	ioTable := luaState.GetGlobal("io")
+	err := luaState.CallByParam(lua.P{
+		Fn:      luaState.GetField(ioTable, "input"),
+		NRet:    1,
+		Protect: true,
+	}, lua.LString(os.DevNull))
+	if err != nil {
+		return err
+	}
+	luaState.SetField(ioTable, "stdin", luaState.Get(-1))
+	luaState.Pop(1)
+	return nil
+}
+
+// luaWriterNoop implements the methods of redirected lua files that have nothing to do.
+func luaWriterNoop(luaState *lua.LState) int {
+	luaState.Push(luaState.Get(1))
+	return 1
+}
+
+// commandExecutor runs a command line that a lua plugin passed to os.execute inside of the given folder and writes the
+// command's output to the given writer.
+type commandExecutor func(ctx context.Context, dir, command string, output io.Writer) error
+
+// commandFilter decides whether a command line that a lua plugin passed to io.popen is run.
+type commandFilter func(command string) bool
+
+// executeShellCommand runs the given command line through the system shell inside of the given folder. The command
+// reads from proji's stdin, so that interactive plugins can prompt the user. It gets killed once the given context is
+// done.
+func executeShellCommand(ctx context.Context, dir, command string, output io.Writer) error {
+	cmd := shellCommand(ctx, command)
+	cmd.Dir = dir
+	cmd.Stdin = os.Stdin
+	return runCommand(ctx, cmd, output, output)
+}
+
+// executeNonInteractiveShellCommand is like executeShellCommand, but the command's stdin is empty. Commands that prompt
+// the user read the end of the input instead of waiting for it.
+func executeNonInteractiveShellCommand(ctx context.Context, dir, command string, output io.Writer) error {
+	cmd := shellCommand(ctx, command)
+	cmd.Dir = dir
+	return runCommand(ctx, cmd, output, output)
+}
+
+// luaExecute returns a replacement for lua's os.execute. Commands are handed to the given command executor together
+// with the plugin's working directory and their output is written to the given writer. Without an argument it reports
+// whether a shell is available.
+func luaExecute(ctx context.Context, dir string, output io.Writer, execute commandExecutor) lua.LGFunction {
+	return func(luaState *lua.LState) int {
+		if luaState.GetTop() == 0 {
+			_, err := exec.LookPath(shell())
+			luaState.Push(lua.LBool(err == nil))
+			return 1
+		}
+
+		err := execute(ctx, dir, luaState.CheckString(1), output)
+		if err != nil {
+			luaState.Push(lua.LNumber(1))
+			return 1
+		}
+		luaState.Push(lua.LNumber(0))
+		return 1
+	}
+}
+
+// luaExitStatus records whether a lua plugin called os.exit and with which status.
+type luaExitStatus struct {
+	called bool
+	status int
+}
+
+// luaExitError is returned if a lua plugin called os.exit with a non-zero exit status.
+type luaExitError struct {
+	status int
+}
+
+func (e *luaExitError) Error() string {
+	return fmt.Sprintf("plugin exited with status %d", e.status)
+}
+
+// luaExit returns a replacement for lua's os.exit. Instead of exiting proji it records the exit status and stops the
+// plugin.
+func luaExit(exit *luaExitStatus) lua.LGFunction {
+	return func(luaState *lua.LState) int {
+		exit.called = true
+		switch status := luaState.Get(1).(type) {
+		case lua.LNumber:
+			exit.status = int(status)
+		case lua.LBool:
+			if !bool(status) {
+				exit.status = 1
+			}
+		}
+		luaState.RaiseError("os.exit(%d)", exit.status)
+		return 0
+	}
+}
+
+// shell returns the system shell.
+func shell() string {
+	if runtime.GOOS == "windows" {
+		return "cmd"
+	}
+	return "/bin/sh"
+}
+
+// shellChangeDirectory prefixes the given command line with a change into the given folder.
+func shellChangeDirectory(dir, command string) string {
+	if runtime.GOOS == "windows" {
+		return fmt.Sprintf("cd /d \"%s\" && %s", dir, command)
+	}
+	return fmt.Sprintf("cd '%s' && %s", strings.ReplaceAll(dir, "'", `'\''`), command)
+}
+
+// shellCommand returns a command which runs the given command line through the system shell.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, shell(), "/C", command)
+	}
+	return exec.CommandContext(ctx, shell(), "-c", command)
+}
@@ -0,0 +1,171 @@
+package projectservice
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunLuaPluginWorkingDirectory(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "proji-lua-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	projectPath := filepath.Join(tempDir, "example")
+	_ = os.Mkdir(projectPath, os.ModePerm)
+	pluginPath := filepath.Join(tempDir, "plugin.lua")
+	_ = ioutil.WriteFile(pluginPath, []byte(`
+local std = require("proji.std")
+local file = assert(io.open("created.txt", "w"))
+file:write("content")
+file:close()
+assert(os.rename("created.txt", "renamed.txt"))
+os.execute("echo command > command.txt")
+proji.variables.exists = tostring(std.is_file("renamed.txt"))
+`), 0o600)
+
+	pc := newPluginContext(tempDir, nil, nil)
+	pc.Project.Path = projectPath
+	var output bytes.Buffer
+	result, err := runLuaPlugin(context.Background(), pluginPath, pc, &output, executeShellCommand, nil, true)
+	if !assert.NoError(t, err, output.String()) {
+		return
+	}
+
+	assert.Equal(t, "true", result.Variables["exists"])
+	assert.FileExists(t, filepath.Join(projectPath, "renamed.txt"))
+	assert.FileExists(t, filepath.Join(projectPath, "command.txt"))
+	assert.NoFileExists(t, "renamed.txt")
+	assert.NoFileExists(t, "command.txt")
+}
+
+func TestRunLuaPluginNonInteractive(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "proji-lua-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	pluginPath := filepath.Join(tempDir, "plugin.lua")
+	_ = ioutil.WriteFile(pluginPath, []byte(`
+proji.variables.stdin = tostring(io.stdin:read())
+proji.variables.read = tostring(io.read())
+`), 0o600)
+
+	pc := newPluginContext(tempDir, nil, nil)
+	pc.Project.Path = tempDir
+	var output bytes.Buffer
+	result, err := runLuaPlugin(context.Background(), pluginPath, pc, &output, executeShellCommand, nil, false)
+	if !assert.NoError(t, err, output.String()) {
+		return
+	}
+	assert.Equal(t, map[string]string{"stdin": "nil", "read": "nil"}, result.Variables)
+}
+
+func TestRunLuaPluginRequireLib(t *testing.T) {
+	configRootPath, err := ioutil.TempDir("", "proji-lua-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(configRootPath)
+	writeTestFiles(t, filepath.Join(configRootPath, "plugins"), map[string]string{
+		"lib/greet.lua":       "return {hello = function(name) return \"hello \" .. name end}\n",
+		"lib/util/init.lua":   "return {name = \"util\"}\n",
+		"lib/string.lua":      "return {shadowed = true}\n",
+		"lib/proji/std.lua":   "return {shadowed = true}\n",
+		"outside.lua":         "return {name = \"outside\"}\n",
+		"require-greet.lua":   "proji.variables.greeting = require(\"greet\").hello(\"proji\")\n",
+		"require-util.lua":    "proji.variables.name = require(\"util\").name\n",
+		"require-missing.lua": "require(\"missing\")\n",
+		"require-outside.lua": "require(\"outside\")\n",
+		"require-builtin.lua": `proji.variables.string = tostring(require("string").shadowed)
+proji.variables.std = tostring(require("proji.std").shadowed)
+proji.variables.upper = string.upper("proji")
+`,
+	})
+
+	tests := []struct {
+		name       string
+		plugin     string
+		want       map[string]string
+		wantErr    string
+		wantModule string
+	}{
+		{name: "Test lib module", plugin: "require-greet.lua", want: map[string]string{"greeting": "hello proji"}},
+		{name: "Test lib package", plugin: "require-util.lua", want: map[string]string{"name": "util"}},
+		{name: "Test missing module", plugin: "require-missing.lua", wantErr: "module missing not found", wantModule: "missing"},
+		{
+			name:       "Test module outside of the lib folder",
+			plugin:     "require-outside.lua",
+			wantErr:    "module outside not found",
+			wantModule: "outside",
+		},
+		{
+			name:   "Test lib modules don't shadow built-in modules",
+			plugin: "require-builtin.lua",
+			want:   map[string]string{"string": "nil", "std": "nil", "upper": "PROJI"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectPath, err := ioutil.TempDir("", "proji-lua-test-")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(projectPath)
+
+			pc := newPluginContext(configRootPath, nil, nil)
+			pc.Project.Path = projectPath
+			var output bytes.Buffer
+			pluginPath := filepath.Join(configRootPath, "plugins", tt.plugin)
+			result, err := runLuaPlugin(context.Background(), pluginPath, pc, &output, executeShellCommand, nil, false)
+			if tt.wantErr != "" {
+				if assert.Error(t, err) {
+					// The error lists where the module was searched for.
+					assert.Contains(t, err.Error(), tt.wantErr)
+					assert.Contains(t, err.Error(), filepath.Join(configRootPath, "plugins", "lib", tt.wantModule+".lua"))
+				}
+				return
+			}
+			if assert.NoError(t, err, output.String()) {
+				assert.Equal(t, tt.want, result.Variables)
+			}
+		})
+	}
+}
+
+func TestExecuteNonInteractiveShellCommand(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "proji-lua-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	_, _ = writer.WriteString("input\n")
+	writer.Close()
+	stdin := os.Stdin
+	os.Stdin = reader
+	defer func() { os.Stdin = stdin }()
+
+	var output bytes.Buffer
+	err = executeNonInteractiveShellCommand(context.Background(), tempDir, "cat > stdin.txt", &output)
+	if !assert.NoError(t, err, output.String()) {
+		return
+	}
+	content, err := ioutil.ReadFile(filepath.Join(tempDir, "stdin.txt"))
+	if assert.NoError(t, err) {
+		assert.Empty(t, content)
+	}
+}
@@ -0,0 +1,58 @@
+package projectservice
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/pelletier/go-toml"
+)
+
+// newManifest returns the manifest of a project that was created from its package with the given files and plugin runs.
+func newManifest(project *domain.Project, files []*domain.ProjectFile, plugins []*domain.ManifestPlugin) *domain.Manifest {
+	manifest := &domain.Manifest{Plugins: plugins}
+	updateManifest(manifest, project, files)
+	return manifest
+}
+
+// updateManifest updates the package, the variables and the files of a manifest after the project was created or
+// updated. The plugins that ran during the creation are kept.
+func updateManifest(manifest *domain.Manifest, project *domain.Project, files []*domain.ProjectFile) {
+	manifest.UpdatedAt = time.Now()
+	if manifest.CreatedAt.IsZero() {
+		manifest.CreatedAt = manifest.UpdatedAt
+	}
+	if project.Package != nil {
+		manifest.PackageName = project.Package.Name
+		manifest.PackageLabel = project.Package.Label
+		manifest.PackageVersion = project.Package.Version
+	}
+	manifest.Variables = project.Variables
+	manifest.Files = make(domain.ManifestFiles, 0, len(files))
+	for _, file := range files {
+		manifest.Files = append(manifest.Files, &domain.ManifestFile{
+			Path:     file.Path,
+			Checksum: fmt.Sprintf("%x", sha256.Sum256(file.Content)),
+			Package:  file.AppliedPackage,
+		})
+	}
+}
+
+// encodeManifest encodes the given manifest as TOML. Keys are sorted alphabetically; preserving the order of the fields
+// sometimes drops or duplicates keys of the manifest.
+func encodeManifest(manifest *domain.Manifest) ([]byte, error) {
+	var buffer bytes.Buffer
+	err := toml.NewEncoder(&buffer).Order(toml.OrderAlphabetical).Encode(*manifest)
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// manifestPath returns the path of the manifest file inside of the given project.
+func manifestPath(projectPath string) string {
+	return filepath.Join(projectPath, filepath.FromSlash(domain.ManifestPath))
+}
@@ -0,0 +1,70 @@
+package projectservice
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewManifest(t *testing.T) {
+	project := &domain.Project{
+		Name:      "example",
+		Package:   &domain.Package{Name: "test", Label: "tst", Version: "1.2.0"},
+		Variables: domain.Variables{"name": "example"},
+	}
+	files := []*domain.ProjectFile{
+		{Path: "empty.txt", Content: nil},
+		{Path: "readme.md", Content: []byte("# example")},
+	}
+	plugins := []*domain.ManifestPlugin{{Path: "init.sh", Phase: "post", ExitStatus: 3, Duration: "1ms", Error: "exit status 3"}}
+
+	manifest := newManifest(project, files, plugins)
+	assert.Equal(t, "test", manifest.PackageName)
+	assert.Equal(t, "tst", manifest.PackageLabel)
+	assert.Equal(t, "1.2.0", manifest.PackageVersion)
+	assert.Equal(t, project.Variables, manifest.Variables)
+	assert.Equal(t, domain.ManifestFiles{
+		{Path: "empty.txt", Checksum: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{Path: "readme.md", Checksum: "b37b032c11ac07c8881f1ac2b41c8c3148e571ec124b9984477661ab9c0ed5b4"},
+	}, manifest.Files)
+	assert.Equal(t, domain.ManifestPlugins(plugins), manifest.Plugins)
+	assert.Equal(t, manifest.CreatedAt, manifest.UpdatedAt)
+
+	// Updates keep the creation time and the plugins.
+	createdAt := manifest.CreatedAt
+	project.Package.Version = "1.3.0"
+	updateManifest(manifest, project, files[:1])
+	assert.Equal(t, "1.3.0", manifest.PackageVersion)
+	assert.Equal(t, createdAt, manifest.CreatedAt)
+	assert.Len(t, manifest.Files, 1)
+	assert.Equal(t, domain.ManifestPlugins(plugins), manifest.Plugins)
+
+	content, err := encodeManifest(manifest)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), `package_version = "1.3.0"`)
+	assert.Contains(t, string(content), "exit_status = 3")
+}
+
+func TestEncodeManifest(t *testing.T) {
+	manifest := &domain.Manifest{
+		PackageName:  "go",
+		PackageLabel: "g",
+		Variables:    domain.Variables{},
+		Files: domain.ManifestFiles{
+			{Path: "README.md", Checksum: "1111"},
+			{Path: "Dockerfile", Checksum: "2222", Package: "dk"},
+		},
+	}
+	// The encoding must be stable, so encode the manifest repeatedly.
+	for i := 0; i < 50; i++ {
+		content, err := encodeManifest(manifest)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, 1, strings.Count(string(content), "created_at"))
+		assert.Equal(t, 2, strings.Count(string(content), "[[file]]"))
+		assert.Contains(t, string(content), `package = "dk"`)
+	}
+}
@@ -0,0 +1,60 @@
+package projectservice
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/nikoksr/proji/pkg/domain"
+)
+
+// namePatterns match the names that are written in one of the name cases.
+var namePatterns = map[string]*regexp.Regexp{
+	domain.NameCaseLower: regexp.MustCompile(`^[^A-Z]*$`),
+	domain.NameCaseUpper: regexp.MustCompile(`^[^a-z]*$`),
+	domain.NameCaseKebab: regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`),
+	domain.NameCaseSnake: regexp.MustCompile(`^[a-z0-9]+(_[a-z0-9]+)*$`),
+}
+
+// validateProjectName checks the name of a project against the name rules of its package.
+func validateProjectName(name string, rules *domain.NameRules) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("project name is empty")
+	}
+	if rules == nil {
+		return nil
+	}
+	for _, reserved := range rules.Reserved {
+		if strings.EqualFold(name, reserved) {
+			return fmt.Errorf("project name %s is reserved", name)
+		}
+	}
+	if rules.Case != "" {
+		pattern, ok := namePatterns[rules.Case]
+		if !ok {
+			return fmt.Errorf("unknown name case %s, expected one of %s", rules.Case, strings.Join(domain.NameCases, ", "))
+		}
+		if !pattern.MatchString(name) {
+			return fmt.Errorf("project name %s is not in %s case", name, rules.Case)
+		}
+	}
+	if rules.Pattern != "" {
+		pattern, err := regexp.Compile(`^(?:` + rules.Pattern + `)$`)
+		if err != nil {
+			return fmt.Errorf("invalid name pattern %s: %v", rules.Pattern, err)
+		}
+		if !pattern.MatchString(name) {
+			return fmt.Errorf("project name %s doesn't match the pattern %s", name, rules.Pattern)
+		}
+	}
+	return nil
+}
+
+// slugPattern matches runs of characters that can't be part of a slug.
+var slugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slugify returns the slug of a project name; its lower case letters and digits, separated by single dashes. For
+// example, "My Great_Project" becomes "my-great-project".
+func slugify(name string) string {
+	return strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
@@ -0,0 +1,52 @@
+package projectservice
+
+import (
+	"testing"
+
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateProjectName(t *testing.T) {
+	rules := &domain.NameRules{
+		Pattern:  "[a-z][a-z0-9-]*",
+		Reserved: []string{"test"},
+		Case:     domain.NameCaseKebab,
+	}
+	tests := []struct {
+		name    string
+		project string
+		rules   *domain.NameRules
+		wantErr bool
+	}{
+		{name: "Test without rules", project: "My Project", rules: nil, wantErr: false},
+		{name: "Test empty name", project: " ", rules: nil, wantErr: true},
+		{name: "Test valid name", project: "my-project", rules: rules, wantErr: false},
+		{name: "Test reserved name", project: "Test", rules: rules, wantErr: true},
+		{name: "Test wrong case", project: "my_project", rules: rules, wantErr: true},
+		{name: "Test pattern matches only part of the name", project: "9-lives", rules: rules, wantErr: true},
+		{name: "Test upper case", project: "MY_PROJECT", rules: &domain.NameRules{Case: domain.NameCaseUpper}, wantErr: false},
+		{name: "Test unknown case", project: "project", rules: &domain.NameRules{Case: "title"}, wantErr: true},
+		{name: "Test invalid pattern", project: "project", rules: &domain.NameRules{Pattern: "("}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateProjectName(tt.project, tt.rules)
+			assert.Equal(t, tt.wantErr, err != nil, "error: %v", err)
+		})
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "my-project", want: "my-project"},
+		{name: "My Great_Project", want: "my-great-project"},
+		{name: "  --API v2--  ", want: "api-v2"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, slugify(tt.name), "name %s", tt.name)
+	}
+}
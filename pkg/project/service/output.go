@@ -0,0 +1,168 @@
+package projectservice
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nikoksr/proji/internal/util"
+	"github.com/nikoksr/proji/pkg/domain"
+)
+
+// maxPendingLine is the number of bytes of an unfinished line that are kept to report it as status. Longer lines are
+// cut at the front, since only their end fits into the status anyway.
+const maxPendingLine = 1024
+
+// pluginOutput collects the combined stdout and stderr of a single plugin or step. It keeps the complete output so that
+// it can be replayed if the plugin fails, mirrors it to the plugin log and reports the latest line to a status sink.
+type pluginOutput struct {
+	mu     sync.Mutex
+	kind   string
+	name   string
+	output bytes.Buffer
+	log    io.Writer
+	status domain.StatusSink
+
+	// pending holds the output after the last newline and last holds the last non-empty line before it. Together they
+	// give the latest line of output without scanning the complete output on every write.
+	pending []byte
+	last    string
+}
+
+func newPluginOutput(kind, name string, log io.Writer, status domain.StatusSink) *pluginOutput {
+	po := &pluginOutput{
+		kind:   kind,
+		name:   name,
+		log:    log,
+		status: status,
+	}
+	if status != nil {
+		status.Write(fmt.Sprintf("running %s %s", kind, name))
+	}
+	return po
+}
+
+// Write implements io.Writer. It is safe to use from multiple goroutines.
+func (po *pluginOutput) Write(p []byte) (int, error) {
+	po.mu.Lock()
+	defer po.mu.Unlock()
+
+	po.output.Write(p)
+	if po.log != nil {
+		_, _ = po.log.Write(p)
+	}
+	if po.status != nil {
+		line := po.latestLine(p)
+		if line != "" {
+			po.status.Write(fmt.Sprintf("%s: %s", po.name, line))
+		}
+	}
+	return len(p), nil
+}
+
+// latestLine adds the given chunk of output to the pending line and returns the latest non-empty line of the output. It
+// only looks at the chunk and the pending line, so reporting the status stays cheap for plugins with a lot of output.
+func (po *pluginOutput) latestLine(p []byte) string {
+	po.pending = append(po.pending, p...)
+	if i := bytes.LastIndexByte(po.pending, '\n'); i >= 0 {
+		if line := lastLine(string(po.pending[:i])); line != "" {
+			po.last = line
+		}
+		po.pending = append([]byte(nil), po.pending[i+1:]...)
+	}
+	if len(po.pending) > maxPendingLine {
+		po.pending = po.pending[len(po.pending)-maxPendingLine:]
+	}
+	if line := strings.TrimSpace(string(po.pending)); line != "" {
+		return line
+	}
+	return po.last
+}
+
+// String returns the complete output of the plugin.
+func (po *pluginOutput) String() string {
+	po.mu.Lock()
+	defer po.mu.Unlock()
+	return po.output.String()
+}
+
+// close reports the final status of the plugin and closes the status sink.
+func (po *pluginOutput) close(err error) {
+	po.mu.Lock()
+	defer po.mu.Unlock()
+
+	if po.status == nil {
+		return
+	}
+	if err != nil {
+		po.status.Write(fmt.Sprintf("%s %s failed", po.kind, po.name))
+	} else {
+		po.status.Write(fmt.Sprintf("%s %s finished", po.kind, po.name))
+	}
+	po.status.Close()
+}
+
+// lastLine returns the last non-empty line of the given text.
+func lastLine(text string) string {
+	text = strings.TrimRight(text, "\r\n")
+	return strings.TrimSpace(text[strings.LastIndex(text, "\n")+1:])
+}
+
+// pluginLog writes the output of all plugins that run for a project to a single log file.
+type pluginLog struct {
+	path string
+	file *os.File
+}
+
+// newPluginLog creates a new log file with the given name in the logs folder of proji's config folder. The name is
+// usually the base name of the project's path.
+func newPluginLog(configRootPath, name string) (*pluginLog, error) {
+	logsPath := filepath.Join(configRootPath, "logs")
+	err := util.CreateFolderIfNotExists(logsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Projects with the same name may be created at the same time, so existing logs get a numbered sibling.
+	baseName := fmt.Sprintf("%s-%s", name, time.Now().Format("20060102-150405"))
+	fileName := baseName + ".log"
+	for i := 2; ; i++ {
+		path := filepath.Join(logsPath, fileName)
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o666)
+		if os.IsExist(err) {
+			fileName = fmt.Sprintf("%s-%d.log", baseName, i)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &pluginLog{path: path, file: file}, nil
+	}
+}
+
+// begin writes a header for a plugin that is about to run.
+func (pl *pluginLog) begin(name string) {
+	_, _ = fmt.Fprintf(pl.file, "==> %s (%s)\n", name, time.Now().Format(time.RFC3339))
+}
+
+// end writes a footer with the result and the duration of a plugin.
+func (pl *pluginLog) end(name string, duration time.Duration, err error) {
+	result := "ok"
+	if err != nil {
+		result = err.Error()
+	}
+	_, _ = fmt.Fprintf(pl.file, "\n<== %s: %s (%s)\n\n", name, result, duration.Round(time.Millisecond))
+}
+
+func (pl *pluginLog) Write(p []byte) (int, error) {
+	return pl.file.Write(p)
+}
+
+func (pl *pluginLog) Close() error {
+	return pl.file.Close()
+}
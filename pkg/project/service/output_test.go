@@ -0,0 +1,41 @@
+package projectservice
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingSink records the status messages that it receives.
+type recordingSink struct {
+	statuses []string
+}
+
+func (s *recordingSink) Write(status string) { s.statuses = append(s.statuses, status) }
+func (s *recordingSink) Close()              {}
+
+func TestPluginOutputStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   string
+	}{
+		{name: "Test single line", chunks: []string{"hello\n"}, want: "tst: hello"},
+		{name: "Test unfinished line", chunks: []string{"first\nsec", "ond"}, want: "tst: second"},
+		{name: "Test line split over chunks", chunks: []string{"fi", "rst\n", "second\n\n"}, want: "tst: second"},
+		{name: "Test trailing blank line", chunks: []string{"first\n", "   \n"}, want: "tst: first"},
+		{name: "Test long line", chunks: []string{strings.Repeat("a", 2*maxPendingLine)}, want: "tst: " + strings.Repeat("a", maxPendingLine)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &recordingSink{}
+			po := newPluginOutput("plugin", "tst", nil, sink)
+			for _, chunk := range tt.chunks {
+				_, _ = po.Write([]byte(chunk))
+			}
+			assert.Equal(t, tt.want, sink.statuses[len(sink.statuses)-1])
+			assert.Equal(t, strings.Join(tt.chunks, ""), po.String())
+		})
+	}
+}
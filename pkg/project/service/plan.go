@@ -0,0 +1,321 @@
+package projectservice
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nikoksr/proji/internal/config"
+	"github.com/nikoksr/proji/internal/rename"
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/pkg/errors"
+)
+
+// PlanProject returns the plan for the creation of the given project without touching the disk. Destinations are
+// rendered with the variables known before any plugin ran.
+func (ps projectService) PlanProject(configRootPath string, project *domain.Project, options *domain.CreateOptions) (*domain.Plan, error) {
+	return newPlan(configRootPath, project, options, ps.templateSettings)
+}
+
+// planner builds the plan for the creation of a project and detects conflicts between its operations and existing
+// files.
+type planner struct {
+	plan              *domain.Plan
+	templatesPath     string
+	variables         domain.Variables
+	templateSettings  *config.Templates
+	rootExists        bool
+	existing          bool
+	plannedOperations map[string]*domain.Operation
+
+	// keepFiles holds the name of the keep file for every folder operation whose template asks for one.
+	keepFiles map[*domain.Operation]string
+}
+
+// newPlan returns the plan for the creation of the given project. The project's root folder is created first, followed
+// by the pre-run plugins and steps, the templates and the post-run plugins and steps. Plugins and steps run together in
+// order of their execution number. The names of files and folders inside of template folders are renamed according to
+// the given template settings.
+func newPlan(
+	configRootPath string, project *domain.Project, options *domain.CreateOptions, templateSettings *config.Templates,
+) (*domain.Plan, error) {
+	if project.Package == nil {
+		return nil, fmt.Errorf("project %s has no package", project.Name)
+	}
+	if options == nil {
+		options = &domain.CreateOptions{}
+	}
+	mergeStrategy, err := getMergeStrategy(options.MergeStrategy)
+	if err != nil {
+		return nil, err
+	}
+	if !options.SkipNameRules {
+		err = validateProjectName(project.Name, project.Package.NameRules)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	p := &planner{
+		plan:              &domain.Plan{Project: project, MergeStrategy: mergeStrategy},
+		templatesPath:     filepath.Join(configRootPath, "templates"),
+		variables:         newRenderVariables(project, options.Variables),
+		templateSettings:  templateSettings,
+		existing:          options.Existing,
+		plannedOperations: make(map[string]*domain.Operation),
+		keepFiles:         make(map[*domain.Operation]string),
+	}
+
+	root := &domain.Operation{Kind: domain.OperationCreateRoot}
+	if info, err := os.Stat(project.Path); err == nil {
+		p.rootExists = true
+		switch {
+		case !p.existing:
+			root.Conflict = "project folder already exists"
+		case !info.IsDir():
+			root.Conflict = "project path is a file"
+		}
+	}
+	p.plan.Operations = append(p.plan.Operations, root)
+
+	p.addTasks(phasePreRun, project.Package.Plugins, project.Package.Steps)
+	for _, template := range project.Package.Templates {
+		err := p.addTemplate(template)
+		if err != nil {
+			return nil, err
+		}
+	}
+	p.addKeepFiles()
+	p.addTasks(phasePostRun, project.Package.Plugins, project.Package.Steps)
+	return p.plan, nil
+}
+
+// addTasks adds an operation for every plugin and step of the given phase in order of their execution number. Plugins
+// and steps with a negative execution number belong to the pre-run phase, those with a positive execution number to the
+// post-run phase. Plugins run before steps with the same execution number. Plugins that registered for a hook never run
+// during the creation of a project.
+func (p *planner) addTasks(phase string, plugins []*domain.Plugin, steps []*domain.Step) {
+	type task struct {
+		execNumber int
+		operation  *domain.Operation
+	}
+	var tasks []task
+	for _, plugin := range plugins {
+		if plugin.Hook != "" || !isInPhase(phase, plugin.ExecNumber) {
+			continue
+		}
+		tasks = append(tasks, task{execNumber: plugin.ExecNumber, operation: &domain.Operation{
+			Kind:   domain.OperationRunPlugin,
+			Source: plugin.Path,
+			Plugin: plugin,
+			Phase:  phase,
+		}})
+	}
+	for _, step := range steps {
+		if !isInPhase(phase, step.ExecNumber) {
+			continue
+		}
+		tasks = append(tasks, task{execNumber: step.ExecNumber, operation: &domain.Operation{
+			Kind:   domain.OperationRunStep,
+			Source: step.Name(),
+			Step:   step,
+			Phase:  phase,
+		}})
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].execNumber < tasks[j].execNumber
+	})
+	for _, task := range tasks {
+		p.plan.Operations = append(p.plan.Operations, task.operation)
+	}
+}
+
+// isInPhase reports whether a plugin or step with the given execution number runs in the given phase.
+func isInPhase(phase string, execNumber int) bool {
+	switch phase {
+	case phasePreRun:
+		return execNumber < 0
+	case phasePostRun:
+		return execNumber > 0
+	default:
+		return false
+	}
+}
+
+// addTemplate adds the operations that create the given template. Template folders are expanded into an operation for
+// each of their files and subfolders. Remote templates are only fetched when the plan is executed.
+func (p *planner) addTemplate(template *domain.Template) error {
+	if template.IsRemote() {
+		if !template.IsFile {
+			return fmt.Errorf("remote template %s is used as a folder, but can only be a file", template.Path)
+		}
+		operation := p.addOperation(templateFileKind(template), template.Path, template.Destination)
+		operation.Template = template
+		return nil
+	}
+	keepFile := template.KeepFile
+	if keepFile == "" {
+		keepFile = p.plan.Project.Package.KeepFile
+	}
+	if len(template.Path) == 0 {
+		kind := domain.OperationCreateFolder
+		if template.IsFile {
+			kind = domain.OperationCreateFile
+		}
+		operation := p.addOperation(kind, "", template.Destination)
+		if !template.IsFile && keepFile != "" {
+			p.keepFiles[operation] = keepFile
+		}
+		return nil
+	}
+
+	source := filepath.Join(p.templatesPath, template.Path)
+	info, err := os.Stat(source)
+	if err != nil {
+		return errors.Wrapf(err, "template %s", template.Path)
+	}
+	if template.IsFile {
+		if info.IsDir() {
+			return fmt.Errorf("template %s is a folder, but is used as a file", template.Path)
+		}
+		p.addOperation(templateFileKind(template), source, template.Destination)
+		return nil
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("template %s is a file, but is used as a folder", template.Path)
+	}
+
+	// Only variables that are known before any plugin ran can be used in names of template files and folders.
+	variableNames := make([]string, 0, len(p.variables))
+	for name := range p.variables {
+		variableNames = append(variableNames, name)
+	}
+	return filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		destination := filepath.Join(template.Destination, rename.TemplatePath(relPath, p.templateSettings, variableNames))
+		if info.IsDir() {
+			operation := p.addOperation(domain.OperationCreateFolder, path, destination)
+			if keepFile != "" {
+				p.keepFiles[operation] = keepFile
+			}
+		} else {
+			p.addOperation(templateFileKind(template), path, destination)
+		}
+		return nil
+	})
+}
+
+// templateFileKind returns the kind of the operations that write the files of the given template. Only templates that
+// opt in are rendered, all others are copied as they are.
+func templateFileKind(template *domain.Template) string {
+	if template.Render {
+		return domain.OperationRenderFile
+	}
+	return domain.OperationCopyFile
+}
+
+// addKeepFiles adds an operation that creates a keep file in every folder that the plan creates, but leaves empty, if
+// the folder's template asks for one. Version control systems like git don't track empty folders, so the keep file
+// makes sure that the folder is part of the project's first commit. Folders that already exist and have content are
+// left alone.
+func (p *planner) addKeepFiles() {
+	for _, folder := range p.plan.Operations {
+		keepFile, ok := p.keepFiles[folder]
+		if !ok || folder.Destination == "." || p.hasContent(folder) {
+			continue
+		}
+		p.addOperation(domain.OperationCreateFile, "", filepath.Join(folder.DestinationTemplate, keepFile))
+	}
+}
+
+// hasContent reports whether the plan creates anything inside of the given folder or whether the folder already exists
+// and isn't empty.
+func (p *planner) hasContent(folder *domain.Operation) bool {
+	prefix := folder.Destination + string(filepath.Separator)
+	for _, operation := range p.plan.Operations {
+		if strings.HasPrefix(operation.Destination, prefix) {
+			return true
+		}
+	}
+	if !p.rootExists {
+		return false
+	}
+	entries, err := ioutil.ReadDir(filepath.Join(p.plan.Project.Path, folder.Destination))
+	return err == nil && len(entries) > 0
+}
+
+// addOperation adds a file or folder operation to the plan and checks it for conflicts.
+func (p *planner) addOperation(kind, source, destinationTemplate string) *domain.Operation {
+	operation := &domain.Operation{
+		Kind:                kind,
+		Source:              source,
+		Destination:         filepath.Clean(render(destinationTemplate, p.variables)),
+		DestinationTemplate: destinationTemplate,
+	}
+	operation.Conflict = p.findConflict(operation)
+	p.plannedOperations[operation.Destination] = operation
+	p.plan.Operations = append(p.plan.Operations, operation)
+	return operation
+}
+
+// findConflict checks if the given operation conflicts with an earlier operation of the plan or with an existing file.
+// Creating a folder that already exists is not a conflict. Existing files are only a conflict if the merge strategy
+// doesn't resolve them.
+func (p *planner) findConflict(operation *domain.Operation) string {
+	isFolder := operation.Kind == domain.OperationCreateFolder
+	if planned, ok := p.plannedOperations[operation.Destination]; ok {
+		if isFolder && planned.Kind == domain.OperationCreateFolder {
+			return ""
+		}
+		if planned.Source != "" {
+			source, err := filepath.Rel(p.templatesPath, planned.Source)
+			if err != nil {
+				source = planned.Source
+			}
+			return fmt.Sprintf("also created from %s", source)
+		}
+		return "also created by another template"
+	}
+	if !p.rootExists {
+		return ""
+	}
+	info, err := os.Stat(filepath.Join(p.plan.Project.Path, operation.Destination))
+	if err != nil {
+		return ""
+	}
+	switch {
+	case isFolder && info.IsDir():
+		return ""
+	case isFolder:
+		return "already exists as a file"
+	case info.IsDir():
+		return "already exists as a folder"
+	}
+	operation.Exists = true
+	if p.existing && p.plan.MergeStrategy != domain.MergeStrategyFail {
+		return ""
+	}
+	return "already exists"
+}
+
+// getMergeStrategy validates the given merge strategy. An empty strategy defaults to domain.MergeStrategyFail.
+func getMergeStrategy(strategy string) (string, error) {
+	if strategy == "" {
+		return domain.MergeStrategyFail, nil
+	}
+	for _, mergeStrategy := range domain.MergeStrategies {
+		if strategy == mergeStrategy {
+			return strategy, nil
+		}
+	}
+	return "", fmt.Errorf("merge strategy %s not supported", strategy)
+}
@@ -0,0 +1,295 @@
+package projectservice
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/nikoksr/proji/internal/config"
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPlan(t *testing.T) {
+	configRootPath, err := ioutil.TempDir("", "proji-plan-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(configRootPath)
+
+	templatesPath := filepath.Join(configRootPath, "templates")
+	_ = os.MkdirAll(filepath.Join(templatesPath, "docs", "api"), os.ModePerm)
+	_ = ioutil.WriteFile(filepath.Join(templatesPath, "readme.md"), []byte("# {{name}}"), 0o600)
+	_ = ioutil.WriteFile(filepath.Join(templatesPath, "docs", "api", "index.md"), []byte(""), 0o600)
+
+	pkg := &domain.Package{
+		Name:  "test",
+		Label: "tst",
+		Templates: []*domain.Template{
+			{IsFile: true, Destination: "{{name}}.md", Path: "readme.md", Render: true},
+			{IsFile: false, Destination: "docs", Path: "docs"},
+			{IsFile: true, Destination: "src/main.go", Path: ""},
+			{IsFile: true, Destination: "docs/api/index.md", Path: ""},
+		},
+		Plugins: []*domain.Plugin{
+			{Path: "post-2.lua", ExecNumber: 2},
+			{Path: "pre-1.lua", ExecNumber: -1},
+			{Path: "hook.sh", ExecNumber: 1, Hook: domain.HookProjectRemoved},
+			{Path: "pre-2.lua", ExecNumber: -2},
+			{Path: "post-1.lua", ExecNumber: 1},
+		},
+		Steps: []*domain.Step{
+			{Type: domain.StepTypeMkdir, ExecNumber: 1, Path: "bin"},
+			{Type: domain.StepTypeRun, ExecNumber: -1, Command: "go", Args: domain.StringList{"mod", "init"}},
+		},
+	}
+
+	type operation struct {
+		Kind        string
+		Source      string
+		Destination string
+		Exists      bool
+		Conflict    string
+	}
+	tests := []struct {
+		name        string
+		projectPath string
+		options     *domain.CreateOptions
+		want        []operation
+		wantErr     bool
+	}{
+		{
+			name:        "Test new project",
+			projectPath: filepath.Join(configRootPath, "example"),
+			want: []operation{
+				{Kind: domain.OperationCreateRoot},
+				{Kind: domain.OperationRunPlugin, Source: "pre-2.lua"},
+				{Kind: domain.OperationRunPlugin, Source: "pre-1.lua"},
+				{Kind: domain.OperationRunStep, Source: "run go mod init"},
+				{Kind: domain.OperationRenderFile, Source: "readme.md", Destination: "example.md"},
+				{Kind: domain.OperationCreateFolder, Source: "docs", Destination: "docs"},
+				{Kind: domain.OperationCreateFolder, Source: "docs/api", Destination: "docs/api"},
+				{Kind: domain.OperationCopyFile, Source: "docs/api/index.md", Destination: "docs/api/index.md"},
+				{Kind: domain.OperationCreateFile, Destination: "src/main.go"},
+				{
+					Kind:        domain.OperationCreateFile,
+					Destination: "docs/api/index.md",
+					Conflict:    "also created from docs/api/index.md",
+				},
+				{Kind: domain.OperationRunPlugin, Source: "post-1.lua"},
+				{Kind: domain.OperationRunStep, Source: "mkdir bin"},
+				{Kind: domain.OperationRunPlugin, Source: "post-2.lua"},
+			},
+		},
+		{
+			name:        "Test existing project",
+			projectPath: templatesPath,
+			want: []operation{
+				{Kind: domain.OperationCreateRoot, Conflict: "project folder already exists"},
+				{Kind: domain.OperationRunPlugin, Source: "pre-2.lua"},
+				{Kind: domain.OperationRunPlugin, Source: "pre-1.lua"},
+				{Kind: domain.OperationRunStep, Source: "run go mod init"},
+				{Kind: domain.OperationRenderFile, Source: "readme.md", Destination: "templates.md"},
+				{Kind: domain.OperationCreateFolder, Source: "docs", Destination: "docs"},
+				{Kind: domain.OperationCreateFolder, Source: "docs/api", Destination: "docs/api"},
+				{
+					Kind:        domain.OperationCopyFile,
+					Source:      "docs/api/index.md",
+					Destination: "docs/api/index.md",
+					Exists:      true,
+					Conflict:    "already exists",
+				},
+				{Kind: domain.OperationCreateFile, Destination: "src/main.go"},
+				{
+					Kind:        domain.OperationCreateFile,
+					Destination: "docs/api/index.md",
+					Conflict:    "also created from docs/api/index.md",
+				},
+				{Kind: domain.OperationRunPlugin, Source: "post-1.lua"},
+				{Kind: domain.OperationRunStep, Source: "mkdir bin"},
+				{Kind: domain.OperationRunPlugin, Source: "post-2.lua"},
+			},
+		},
+		{
+			name:        "Test existing folder with skip strategy",
+			projectPath: templatesPath,
+			options:     &domain.CreateOptions{Existing: true, MergeStrategy: domain.MergeStrategySkip},
+			want: []operation{
+				{Kind: domain.OperationCreateRoot},
+				{Kind: domain.OperationRunPlugin, Source: "pre-2.lua"},
+				{Kind: domain.OperationRunPlugin, Source: "pre-1.lua"},
+				{Kind: domain.OperationRunStep, Source: "run go mod init"},
+				{Kind: domain.OperationRenderFile, Source: "readme.md", Destination: "templates.md"},
+				{Kind: domain.OperationCreateFolder, Source: "docs", Destination: "docs"},
+				{Kind: domain.OperationCreateFolder, Source: "docs/api", Destination: "docs/api"},
+				{Kind: domain.OperationCopyFile, Source: "docs/api/index.md", Destination: "docs/api/index.md", Exists: true},
+				{Kind: domain.OperationCreateFile, Destination: "src/main.go"},
+				{
+					Kind:        domain.OperationCreateFile,
+					Destination: "docs/api/index.md",
+					Conflict:    "also created from docs/api/index.md",
+				},
+				{Kind: domain.OperationRunPlugin, Source: "post-1.lua"},
+				{Kind: domain.OperationRunStep, Source: "mkdir bin"},
+				{Kind: domain.OperationRunPlugin, Source: "post-2.lua"},
+			},
+		},
+		{
+			name:        "Test unknown merge strategy",
+			projectPath: templatesPath,
+			options:     &domain.CreateOptions{Existing: true, MergeStrategy: "merge"},
+			wantErr:     true,
+		},
+		{
+			name:        "Test missing template",
+			projectPath: filepath.Join(configRootPath, "missing"),
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testPkg := *pkg
+			if tt.wantErr && tt.options == nil {
+				testPkg.Templates = []*domain.Template{{IsFile: true, Destination: "a", Path: "missing.md"}}
+			}
+			project := domain.NewProject(filepath.Base(tt.projectPath), tt.projectPath, &testPkg)
+
+			plan, err := newPlan(configRootPath, project, tt.options, nil)
+			assert.Equal(t, tt.wantErr, err != nil, "newPlan() error = %v, wantErr %v", err, tt.wantErr)
+			if tt.wantErr {
+				return
+			}
+
+			got := make([]operation, 0, len(plan.Operations))
+			for _, op := range plan.Operations {
+				source := op.Source
+				if op.Kind != domain.OperationRunPlugin && op.Kind != domain.OperationRunStep && source != "" {
+					source, _ = filepath.Rel(templatesPath, source)
+				}
+				got = append(got, operation{
+					Kind:        op.Kind,
+					Source:      filepath.ToSlash(source),
+					Destination: filepath.ToSlash(op.Destination),
+					Exists:      op.Exists,
+					Conflict:    op.Conflict,
+				})
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewPlanKeepFiles(t *testing.T) {
+	configRootPath, err := ioutil.TempDir("", "proji-plan-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(configRootPath)
+
+	templatesPath := filepath.Join(configRootPath, "templates")
+	_ = os.MkdirAll(filepath.Join(templatesPath, "layout", "cache"), os.ModePerm)
+	_ = os.MkdirAll(filepath.Join(templatesPath, "layout", "lib"), os.ModePerm)
+	_ = ioutil.WriteFile(filepath.Join(templatesPath, "layout", "lib", "main.go"), []byte(""), 0o600)
+
+	// The logs folder of the existing project already has content.
+	existingPath := filepath.Join(configRootPath, "existing")
+	_ = os.MkdirAll(filepath.Join(existingPath, "logs"), os.ModePerm)
+	_ = ioutil.WriteFile(filepath.Join(existingPath, "logs", "app.log"), []byte(""), 0o600)
+
+	pkg := &domain.Package{
+		Name:     "test",
+		Label:    "tst",
+		KeepFile: ".gitkeep",
+		Templates: []*domain.Template{
+			{IsFile: false, Destination: "web", Path: "layout", KeepFile: ".keep"},
+			{IsFile: false, Destination: "logs"},
+			{IsFile: false, Destination: "data"},
+			{IsFile: true, Destination: "data/seed.sql"},
+		},
+	}
+	tests := []struct {
+		name        string
+		projectPath string
+		options     *domain.CreateOptions
+		want        []string
+	}{
+		{
+			name:        "Test new project",
+			projectPath: filepath.Join(configRootPath, "example"),
+			want:        []string{"data/seed.sql", "web/cache/.keep", "logs/.gitkeep"},
+		},
+		{
+			name:        "Test existing folder with content",
+			projectPath: existingPath,
+			options:     &domain.CreateOptions{Existing: true, MergeStrategy: domain.MergeStrategySkip},
+			want:        []string{"data/seed.sql", "web/cache/.keep"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := domain.NewProject(filepath.Base(tt.projectPath), tt.projectPath, pkg)
+			plan, err := newPlan(configRootPath, project, tt.options, nil)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			var got []string
+			for _, op := range plan.Operations {
+				if op.Kind == domain.OperationCreateFile {
+					got = append(got, filepath.ToSlash(op.Destination))
+				}
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// statusRecorder records the statuses that are written to the sinks it returns.
+type statusRecorder struct {
+	mu       sync.Mutex
+	statuses []string
+}
+
+func (r *statusRecorder) newSink() domain.StatusSink {
+	return r
+}
+
+func (r *statusRecorder) Write(status string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statuses = append(r.statuses, status)
+}
+
+func (r *statusRecorder) Close() {}
+
+func TestCreateProjectReportsConflicts(t *testing.T) {
+	configRootPath, err := ioutil.TempDir("", "proji-plan-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(configRootPath)
+	writeTestFiles(t, filepath.Join(configRootPath, "templates"), map[string]string{
+		"readme.md":       "# readme\n",
+		"other/readme.md": "# other\n",
+	})
+
+	// Both templates create the same file.
+	pkg := domain.NewPackage("test", "tst")
+	pkg.Templates = []*domain.Template{
+		{IsFile: true, Destination: "README.md", Path: "readme.md"},
+		{IsFile: true, Destination: "README.md", Path: "other/readme.md"},
+	}
+	project := domain.NewProject("example", filepath.Join(configRootPath, "example"), pkg)
+	status := &statusRecorder{}
+	ps := projectService{templateSettings: &config.Templates{}, projectStore: &projectStoreStub{}}
+
+	err = ps.CreateProject(context.Background(), configRootPath, project, &domain.CreateOptions{NewStatusSink: status.newSink})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, status.statuses, "conflict at README.md: also created from readme.md")
+	assertFileContent(t, filepath.Join(project.Path, "README.md"), "# other\n")
+}
@@ -0,0 +1,403 @@
+package projectservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/pkg/errors"
+)
+
+// Plugin phases describe at which point of the project creation a plugin is run.
+const (
+	phasePreRun  = "pre"
+	phasePostRun = "post"
+)
+
+// pluginContext holds information about the project that is being created. It is passed to every plugin, either as
+// JSON on stdin and environment variables for executable plugins or as the global proji table for lua plugins.
+type pluginContext struct {
+	Phase      string            `json:"phase"`
+	ConfigPath string            `json:"config_path"`
+	Project    pluginProject     `json:"project"`
+	Package    pluginPackage     `json:"package"`
+	Variables  map[string]string `json:"variables"`
+	Error      string            `json:"error,omitempty"`
+}
+
+type pluginProject struct {
+	Name         string `json:"name"`
+	Path         string `json:"path"`
+	PreviousPath string `json:"previous_path,omitempty"`
+}
+
+type pluginPackage struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+}
+
+// pluginResult holds the optional data a plugin hands back to proji after it ran.
+type pluginResult struct {
+	Variables map[string]string `json:"variables"`
+}
+
+// newPluginContext returns a plugin context for the given project and package. The project is nil for plugins that
+// run on a package hook.
+func newPluginContext(configRootPath string, project *domain.Project, pkg *domain.Package) *pluginContext {
+	pc := &pluginContext{
+		ConfigPath: configRootPath,
+		Variables:  make(map[string]string),
+	}
+	if project != nil {
+		pc.Project = pluginProject{
+			Name: project.Name,
+			Path: project.Path,
+		}
+	}
+	if pkg != nil {
+		pc.Package = pluginPackage{
+			Name:  pkg.Name,
+			Label: pkg.Label,
+		}
+	}
+	return pc
+}
+
+// workingDirectory returns the folder that a plugin runs in. Plugins run inside of the project's folder, unless it
+// doesn't exist, e.g. for plugins that run after a project was removed. In that case they inherit proji's working
+// directory.
+func (pc *pluginContext) workingDirectory() string {
+	if pc.Project.Path == "" {
+		return ""
+	}
+	info, err := os.Stat(pc.Project.Path)
+	if err != nil || !info.IsDir() {
+		return ""
+	}
+	return pc.Project.Path
+}
+
+// environment returns the plugin context as a list of environment variables in the form of "key=value". Variables are
+// passed as PROJI_VAR_<NAME>; see envVariableName for how their names are mapped.
+func (pc *pluginContext) environment() []string {
+	env := []string{
+		"PROJI_PHASE=" + pc.Phase,
+		"PROJI_CONFIG_PATH=" + pc.ConfigPath,
+		"PROJI_PROJECT_NAME=" + pc.Project.Name,
+		"PROJI_PROJECT_PATH=" + pc.Project.Path,
+		"PROJI_PACKAGE_NAME=" + pc.Package.Name,
+		"PROJI_PACKAGE_LABEL=" + pc.Package.Label,
+	}
+	if pc.Project.PreviousPath != "" {
+		env = append(env, "PROJI_PROJECT_PREVIOUS_PATH="+pc.Project.PreviousPath)
+	}
+	if pc.Error != "" {
+		env = append(env, "PROJI_ERROR="+pc.Error)
+	}
+	keys := make([]string, 0, len(pc.Variables))
+	for key := range pc.Variables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		env = append(env, "PROJI_VAR_"+envVariableName(key)+"="+pc.Variables[key])
+	}
+	return env
+}
+
+// envVariableName maps the name of a plugin variable to a valid name of an environment variable. Letters are turned
+// into upper case and every character other than A-Z, 0-9 and _ is replaced with an underscore, e.g. go.version and
+// go-version both become GO_VERSION. Names that collide are passed in the order of the variable names, so the last one
+// wins; the original names are always available in the JSON on stdin.
+func envVariableName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// merge adds the variables of a plugin result to the context, so that they are available to all following plugins.
+func (pc *pluginContext) merge(result *pluginResult) {
+	if result == nil {
+		return
+	}
+	for key, value := range result.Variables {
+		pc.Variables[key] = value
+	}
+}
+
+// pluginType returns the type of the given plugin. If the plugin has no explicit type, the type is inferred from the
+// plugin's file extension; lua files are run as lua plugins, everything else as an executable.
+func pluginType(plugin *domain.Plugin) string {
+	if plugin.Type != "" {
+		return plugin.Type
+	}
+	if strings.EqualFold(filepath.Ext(plugin.Path), ".lua") {
+		return domain.PluginTypeLua
+	}
+	return domain.PluginTypeExecutable
+}
+
+// phaseName returns a readable name of the given phase for messages and logs.
+func phaseName(phase string) string {
+	switch phase {
+	case phasePreRun, phasePostRun:
+		return phase + "-run"
+	default:
+		return phase + " hook"
+	}
+}
+
+// sortPlugins returns the given plugins sorted by their execution number.
+func sortPlugins(plugins []*domain.Plugin) []*domain.Plugin {
+	sorted := make([]*domain.Plugin, len(plugins))
+	copy(sorted, plugins)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ExecNumber < sorted[j].ExecNumber
+	})
+	return sorted
+}
+
+// pluginRunner runs the plugins of a single project or package. All plugins share the same plugin context and write
+// their output to the same plugin log.
+type pluginRunner struct {
+	configRootPath string
+	defaultTimeout time.Duration
+	logName        string
+	context        *pluginContext
+	log            *pluginLog
+	newStatusSink  func() domain.StatusSink
+	executeCommand commandExecutor
+	popenCommand   commandFilter
+	nonInteractive bool
+	lastOutput     string
+	runs           []*domain.ManifestPlugin
+}
+
+// newPluginRunner returns a plugin runner for the given project and package. The project is nil for plugins that run
+// on a package hook.
+func (ps projectService) newPluginRunner(
+	configRootPath string, project *domain.Project, pkg *domain.Package, newStatusSink func() domain.StatusSink,
+) *pluginRunner {
+	pr := &pluginRunner{
+		configRootPath: configRootPath,
+		context:        newPluginContext(configRootPath, project, pkg),
+		newStatusSink:  newStatusSink,
+		executeCommand: executeShellCommand,
+	}
+	if ps.pluginSettings != nil {
+		pr.defaultTimeout = ps.pluginSettings.Timeout
+	}
+	switch {
+	case project != nil:
+		pr.logName = filepath.Base(project.Path)
+	case pkg != nil:
+		pr.logName = pkg.Label
+	default:
+		pr.logName = "proji"
+	}
+	return pr
+}
+
+// disableInput keeps lua plugins and the commands that they run from reading proji's stdin. They read an empty input
+// instead.
+func (pr *pluginRunner) disableInput() {
+	pr.executeCommand = executeNonInteractiveShellCommand
+	pr.nonInteractive = true
+}
+
+// close closes the plugin log if it was opened.
+func (pr *pluginRunner) close() error {
+	if pr.log == nil {
+		return nil
+	}
+	return pr.log.Close()
+}
+
+// taskTimeout returns the time a plugin or step may run before it gets canceled. Its own timeout takes precedence over
+// the global plugin timeout. A timeout of zero means that it may run forever.
+func (pr *pluginRunner) taskTimeout(timeout string) (time.Duration, error) {
+	if timeout != "" {
+		return time.ParseDuration(timeout)
+	}
+	return pr.defaultTimeout, nil
+}
+
+// logPath returns the path of the plugin log or an empty string if it wasn't opened yet.
+func (pr *pluginRunner) logPath() string {
+	if pr.log == nil {
+		return ""
+	}
+	return pr.log.path
+}
+
+// run runs a plugin based on its type and merges its result into the plugin context. The plugin gets canceled if it
+// exceeds its timeout or if the given context gets canceled. The plugin's output is captured; it's written to the
+// plugin log, reported to a status sink and attached to the returned error if the plugin fails.
+func (pr *pluginRunner) run(ctx context.Context, plugin *domain.Plugin) error {
+	// Plugin path is relative by default to make it shareable. We have to make it an absolute path here,
+	// so that we can execute it.
+	pluginPath := plugin.Path
+	if !filepath.IsAbs(pluginPath) {
+		pluginPath = filepath.Join(pr.configRootPath, "plugins", plugin.Path)
+	}
+
+	task := &runnerTask{kind: "plugin", name: plugin.Path, timeout: plugin.Timeout}
+	output, timeout, err := pr.runTask(ctx, task, func(ctx context.Context, output io.Writer) (*pluginResult, error) {
+		switch pluginType(plugin) {
+		case domain.PluginTypeLua:
+			return runLuaPlugin(ctx, pluginPath, pr.context, output, pr.executeCommand, pr.popenCommand, !pr.nonInteractive)
+		case domain.PluginTypeExecutable:
+			return runExecutablePlugin(ctx, pluginPath, pr.context, output)
+		default:
+			return nil, fmt.Errorf("plugin type %s not supported", plugin.Type)
+		}
+	})
+	if err != nil {
+		return &PluginError{
+			Path:    plugin.Path,
+			Phase:   phaseName(pr.context.Phase),
+			Timeout: timeout,
+			Output:  output,
+			LogPath: pr.logPath(),
+			Err:     err,
+		}
+	}
+	return nil
+}
+
+// runnerTask describes a plugin or a step that the plugin runner runs.
+type runnerTask struct {
+	// kind is either "plugin" or "step".
+	kind    string
+	name    string
+	timeout string
+}
+
+// runTask runs a plugin or a step. It applies the timeout, writes the output to the plugin log and a status sink,
+// records the run for the manifest and merges a successful result into the plugin context. It returns the complete
+// output and the timeout that applied.
+func (pr *pluginRunner) runTask(
+	ctx context.Context, task *runnerTask, run func(context.Context, io.Writer) (*pluginResult, error),
+) (string, time.Duration, error) {
+	timeout, err := pr.taskTimeout(task.timeout)
+	if err != nil {
+		return "", 0, errors.Wrap(err, "parse timeout")
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// Open the plugin log on the first run, so that projects without plugins don't leave empty logs behind.
+	if pr.log == nil {
+		pr.log, err = newPluginLog(pr.configRootPath, pr.logName)
+		if err != nil {
+			return "", timeout, errors.Wrap(err, "create plugin log")
+		}
+	}
+
+	var status domain.StatusSink
+	if pr.newStatusSink != nil {
+		status = pr.newStatusSink()
+	}
+	name := fmt.Sprintf("%s (%s)", task.name, phaseName(pr.context.Phase))
+	output := newPluginOutput(task.kind, name, pr.log, status)
+	pr.log.begin(name)
+	started := time.Now()
+
+	result, err := run(ctx, output)
+
+	// Report the context's error if the task failed because it timed out or was canceled.
+	if err != nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	duration := time.Since(started)
+	pr.log.end(name, duration, err)
+	output.close(err)
+	pr.lastOutput = output.String()
+	taskRun := &domain.ManifestPlugin{
+		Path:       task.name,
+		Phase:      pr.context.Phase,
+		Step:       task.kind == "step",
+		ExitStatus: exitStatus(err),
+		Duration:   duration.Round(time.Millisecond).String(),
+	}
+	if err != nil {
+		taskRun.Error = err.Error()
+	}
+	pr.runs = append(pr.runs, taskRun)
+	if err != nil {
+		return output.String(), timeout, err
+	}
+	pr.context.merge(result)
+	return output.String(), timeout, nil
+}
+
+// exitStatus returns the exit status of a plugin that finished with the given error. It is -1 if the plugin didn't exit
+// on its own, e.g. because it timed out or couldn't be started.
+func exitStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	var luaErr *luaExitError
+	if errors.As(err, &luaErr) {
+		return luaErr.status
+	}
+	return -1
+}
+
+// runHook runs all plugins that registered for the given hook in order of their execution number.
+func (pr *pluginRunner) runHook(ctx context.Context, hook string, plugins []*domain.Plugin) error {
+	pr.context.Phase = hook
+	for _, plugin := range sortPlugins(plugins) {
+		if plugin.Hook != hook {
+			continue
+		}
+		err := pr.run(ctx, plugin)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parsePluginOutput tries to read a plugin result from the stdout of a plugin. Either the whole output or its last line
+// have to be a JSON object.
+func parsePluginOutput(stdout []byte) (*pluginResult, bool) {
+	trimmed := bytes.TrimSpace(stdout)
+	if len(trimmed) == 0 {
+		return nil, false
+	}
+
+	result := &pluginResult{}
+	if json.Unmarshal(trimmed, result) == nil {
+		return result, true
+	}
+
+	lastLineStart := bytes.LastIndexByte(trimmed, '\n') + 1
+	if json.Unmarshal(trimmed[lastLineStart:], result) == nil {
+		return result, true
+	}
+	return nil, false
+}
@@ -0,0 +1,236 @@
+package projectservice
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nikoksr/proji/internal/config"
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPluginRunnerTimeout(t *testing.T) {
+	configRootPath, err := ioutil.TempDir("", "proji-plugin-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(configRootPath)
+	writeTestFiles(t, filepath.Join(configRootPath, "plugins"), map[string]string{
+		"sleep.sh":  "#!/bin/sh\necho sleeping\nexec sleep 5\n",
+		"quick.sh":  "#!/bin/sh\nsleep 0.2\necho done\n",
+		"sleep.lua": "while true do end\n",
+	})
+	for _, name := range []string{"sleep.sh", "quick.sh"} {
+		err = os.Chmod(filepath.Join(configRootPath, "plugins", name), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		plugin        *domain.Plugin
+		globalTimeout time.Duration
+		cancel        bool
+		wantErr       error
+		wantMessage   string
+	}{
+		{
+			name:        "Test plugin timeout",
+			plugin:      &domain.Plugin{Path: "sleep.sh", Timeout: "100ms"},
+			wantErr:     context.DeadlineExceeded,
+			wantMessage: "plugin sleep.sh (pre-run) timed out after 100ms",
+		},
+		{
+			name:          "Test global timeout",
+			plugin:        &domain.Plugin{Path: "sleep.sh"},
+			globalTimeout: 100 * time.Millisecond,
+			wantErr:       context.DeadlineExceeded,
+			wantMessage:   "plugin sleep.sh (pre-run) timed out after 100ms",
+		},
+		{
+			name:          "Test lua plugin timeout",
+			plugin:        &domain.Plugin{Path: "sleep.lua"},
+			globalTimeout: 100 * time.Millisecond,
+			wantErr:       context.DeadlineExceeded,
+			wantMessage:   "plugin sleep.lua (pre-run) timed out after 100ms",
+		},
+		{
+			name:          "Test plugin timeout overrides global timeout",
+			plugin:        &domain.Plugin{Path: "quick.sh", Timeout: "5s"},
+			globalTimeout: 50 * time.Millisecond,
+		},
+		{
+			name:          "Test disabled plugin timeout",
+			plugin:        &domain.Plugin{Path: "quick.sh", Timeout: "0"},
+			globalTimeout: 50 * time.Millisecond,
+		},
+		{
+			name:        "Test canceled context",
+			plugin:      &domain.Plugin{Path: "sleep.sh"},
+			cancel:      true,
+			wantErr:     context.Canceled,
+			wantMessage: "plugin sleep.sh (pre-run) was canceled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := projectService{pluginSettings: &config.Plugins{Timeout: tt.globalTimeout}}
+			project := domain.NewProject("example", configRootPath, nil)
+			runner := ps.newPluginRunner(configRootPath, project, nil, nil)
+			defer runner.close()
+			runner.context.Phase = phasePreRun
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				time.AfterFunc(100*time.Millisecond, cancel)
+			}
+			started := time.Now()
+			err := runner.run(ctx, tt.plugin)
+			assert.Less(t, int64(time.Since(started)), int64(3*time.Second), "plugin wasn't stopped")
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var pluginErr *PluginError
+			if !assert.True(t, errors.As(err, &pluginErr), "error %v is no plugin error", err) {
+				return
+			}
+			assert.True(t, errors.Is(err, tt.wantErr), "error %v is no %v", err, tt.wantErr)
+			assert.Equal(t, tt.wantMessage, err.Error())
+			assert.Equal(t, runner.logPath(), pluginErr.LogPath)
+			if assert.Len(t, runner.runs, 1) {
+				assert.Equal(t, -1, runner.runs[0].ExitStatus)
+			}
+		})
+	}
+}
+
+func TestPluginErrorMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *PluginError
+		want string
+	}{
+		{
+			name: "Test failed plugin",
+			err:  &PluginError{Path: "init.lua", Phase: "post-run", Err: errors.New("exit status 1")},
+			want: "plugin init.lua (post-run) failed: exit status 1",
+		},
+		{
+			name: "Test timed out plugin",
+			err:  &PluginError{Path: "init.lua", Phase: "post-run", Timeout: time.Minute, Err: context.DeadlineExceeded},
+			want: "plugin init.lua (post-run) timed out after 1m0s",
+		},
+		{
+			name: "Test canceled plugin",
+			err:  &PluginError{Path: "init.lua", Phase: "project-removed hook", Err: context.Canceled},
+			want: "plugin init.lua (project-removed hook) was canceled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.err.Error())
+		})
+	}
+}
+
+func TestParsePluginOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		stdout string
+		want   *pluginResult
+		wantOK bool
+	}{
+		{
+			name:   "Test whole output",
+			stdout: `{"variables": {"go_version": "1.15"}}`,
+			want:   &pluginResult{Variables: map[string]string{"go_version": "1.15"}},
+			wantOK: true,
+		},
+		{
+			name:   "Test multi-line object",
+			stdout: "{\n  \"variables\": {\n    \"go_version\": \"1.15\"\n  }\n}\n",
+			want:   &pluginResult{Variables: map[string]string{"go_version": "1.15"}},
+			wantOK: true,
+		},
+		{
+			name:   "Test last line",
+			stdout: "Detecting go version...\n{\"variables\": {\"go_version\": \"1.15\"}}\n",
+			want:   &pluginResult{Variables: map[string]string{"go_version": "1.15"}},
+			wantOK: true,
+		},
+		{
+			name:   "Test object without variables",
+			stdout: "done\n{}\n",
+			want:   &pluginResult{},
+			wantOK: true,
+		},
+		{name: "Test empty output", stdout: ""},
+		{name: "Test whitespace", stdout: " \n\t\n"},
+		{name: "Test plain text", stdout: "Detecting go version...\ndone\n"},
+		{name: "Test object not on last line", stdout: "{\"variables\": {\"go_version\": \"1.15\"}}\ndone\n"},
+		{name: "Test invalid JSON", stdout: "{\"variables\": {\"go_version\": }\n"},
+		{name: "Test wrong variable type", stdout: `{"variables": {"go_version": 1.15}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parsePluginOutput([]byte(tt.stdout))
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPluginContextEnvironment(t *testing.T) {
+	pc := &pluginContext{
+		Phase:      phasePostRun,
+		ConfigPath: "/home/user/.config/proji",
+		Project:    pluginProject{Name: "example", Path: "/tmp/example"},
+		Package:    pluginPackage{Name: "go", Label: "g"},
+		Variables: map[string]string{
+			"go_version": "1.15",
+			"app.name":   "example-app",
+			"db-host":    "localhost",
+			"Port2":      "8080",
+		},
+	}
+	assert.ElementsMatch(t, []string{
+		"PROJI_PHASE=post",
+		"PROJI_CONFIG_PATH=/home/user/.config/proji",
+		"PROJI_PROJECT_NAME=example",
+		"PROJI_PROJECT_PATH=/tmp/example",
+		"PROJI_PACKAGE_NAME=go",
+		"PROJI_PACKAGE_LABEL=g",
+		"PROJI_VAR_GO_VERSION=1.15",
+		"PROJI_VAR_APP_NAME=example-app",
+		"PROJI_VAR_DB_HOST=localhost",
+		"PROJI_VAR_PORT2=8080",
+	}, pc.environment())
+}
+
+func TestEnvVariableName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "go_version", want: "GO_VERSION"},
+		{name: "go.version", want: "GO_VERSION"},
+		{name: "go-version", want: "GO_VERSION"},
+		{name: "Port2", want: "PORT2"},
+		{name: "app name=x", want: "APP_NAME_X"},
+		{name: "größe", want: "GR__E"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, envVariableName(tt.name))
+		})
+	}
+}
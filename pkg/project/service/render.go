@@ -0,0 +1,71 @@
+package projectservice
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nikoksr/proji/pkg/domain"
+)
+
+// variablePattern matches placeholders like {{ go_version }} in templates.
+var variablePattern = regexp.MustCompile(`{{\s*([A-Za-z_][A-Za-z0-9_.-]*)\s*}}`)
+
+// newRenderVariables returns the variables that templates of the given project are rendered with. Variables set by
+// plugins are merged over the project's base variables.
+func newRenderVariables(project *domain.Project, pluginVariables map[string]string) domain.Variables {
+	variables := domain.Variables{
+		domain.VariableName: project.Name,
+		domain.VariableSlug: slugify(project.Name),
+		domain.VariablePath: project.Path,
+	}
+	if project.Package != nil {
+		variables[domain.VariablePackageName] = project.Package.Name
+		variables[domain.VariablePackageLabel] = project.Package.Label
+	}
+	for key, value := range pluginVariables {
+		variables[key] = value
+	}
+	return variables
+}
+
+// render replaces all placeholders of known variables in the given text. Placeholders of unknown variables are left
+// untouched, so that templates may contain other template languages which use the same syntax.
+func render(text string, variables domain.Variables) string {
+	return string(renderBytes([]byte(text), variables))
+}
+
+// renderBytes is like render but works on the content of a file. Binary content is returned as is.
+func renderBytes(content []byte, variables domain.Variables) []byte {
+	if !bytes.Contains(content, []byte("{{")) || !isText(content) {
+		return content
+	}
+	return variablePattern.ReplaceAllFunc(content, func(placeholder []byte) []byte {
+		name := string(variablePattern.FindSubmatch(placeholder)[1])
+		value, ok := variables[name]
+		if !ok {
+			return placeholder
+		}
+		return []byte(value)
+	})
+}
+
+// isText reports whether the given content is text. Like git, content is considered binary if it isn't valid UTF-8 or
+// contains a NUL byte.
+func isText(content []byte) bool {
+	return utf8.Valid(content) && bytes.IndexByte(content, 0) < 0
+}
+
+// NextSteps returns the next steps message of the project's package, rendered with the variables of the project. It's
+// empty if the package has no such message.
+func (ps projectService) NextSteps(project *domain.Project) string {
+	if project.Package == nil || project.Package.NextSteps == "" {
+		return ""
+	}
+	variables := project.Variables
+	if variables == nil {
+		variables = newRenderVariables(project, nil)
+	}
+	return strings.TrimSpace(render(project.Package.NextSteps, variables))
+}
@@ -0,0 +1,118 @@
+package projectservice
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderBytes(t *testing.T) {
+	variables := domain.Variables{"name": "My Project", "slug": "my-project", "go_version": "1.15"}
+	tests := []struct {
+		name    string
+		content []byte
+		want    []byte
+	}{
+		{name: "Test no placeholders", content: []byte("# Readme"), want: []byte("# Readme")},
+		{name: "Test known variables", content: []byte("# {{name}} ({{ slug }})"), want: []byte("# My Project (my-project)")},
+		{name: "Test dotted variable", content: []byte("go {{go_version}}"), want: []byte("go 1.15")},
+		{
+			name:    "Test unknown variables",
+			content: []byte("image: {{ .Values.image }}\nname: {{ name }}\nrun: ${{ github.sha }}"),
+			want:    []byte("image: {{ .Values.image }}\nname: My Project\nrun: ${{ github.sha }}"),
+		},
+		{name: "Test jinja expression", content: []byte("{{ name | upper }}"), want: []byte("{{ name | upper }}")},
+		{name: "Test binary content", content: []byte("\x00\x01{{name}}\x00"), want: []byte("\x00\x01{{name}}\x00")},
+		{name: "Test non-UTF-8 content", content: []byte("caf\xe9 {{name}}"), want: []byte("caf\xe9 {{name}}")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, renderBytes(tt.content, variables))
+		})
+	}
+}
+
+func TestReadTemplate(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "proji-render-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	path := filepath.Join(tempDir, "chart.yaml")
+	err = ioutil.WriteFile(path, []byte("name: {{ name }}\nimage: {{ .Values.image }}"), 0o640)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	variables := domain.Variables{"name": "example"}
+	tests := []struct {
+		name string
+		kind string
+		want string
+	}{
+		{name: "Test copy", kind: domain.OperationCopyFile, want: "name: {{ name }}\nimage: {{ .Values.image }}"},
+		{name: "Test render", kind: domain.OperationRenderFile, want: "name: example\nimage: {{ .Values.image }}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, perm, err := readTemplate(&domain.Operation{Kind: tt.kind, Source: path}, variables)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.want, string(content))
+			assert.Equal(t, os.FileMode(0o640), perm)
+		})
+	}
+}
+
+func TestNewRenderVariables(t *testing.T) {
+	project := &domain.Project{Name: "My Project", Path: "/tmp/my-project", Package: domain.NewPackage("go", "g")}
+	got := newRenderVariables(project, map[string]string{"go_version": "1.15"})
+	want := domain.Variables{
+		domain.VariableName:         "My Project",
+		domain.VariableSlug:         "my-project",
+		domain.VariablePath:         "/tmp/my-project",
+		domain.VariablePackageName:  "go",
+		domain.VariablePackageLabel: "g",
+		"go_version":                "1.15",
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestNextSteps(t *testing.T) {
+	pkg := domain.NewPackage("go", "g")
+	pkg.NextSteps = "\n  cd {{ slug }} && make {{ target }}\n"
+	tests := []struct {
+		name    string
+		project *domain.Project
+		want    string
+	}{
+		{name: "Test without package", project: domain.NewProject("My Project", "/tmp/my-project", nil)},
+		{name: "Test without next steps", project: domain.NewProject("My Project", "/tmp/my-project", domain.NewPackage("go", "g"))},
+		{
+			name:    "Test base variables",
+			project: domain.NewProject("My Project", "/tmp/my-project", pkg),
+			want:    "cd my-project && make {{ target }}",
+		},
+		{
+			name: "Test stored variables",
+			project: &domain.Project{
+				Name:      "My Project",
+				Path:      "/tmp/my-project",
+				Package:   pkg,
+				Variables: domain.Variables{"slug": "api", "target": "dev"},
+			},
+			want: "cd api && make dev",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, projectService{}.NextSteps(tt.project))
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package projectservice
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nikoksr/proji/pkg/domain"
+)
+
+// versionTimeout is the time a tool may take to report its version.
+const versionTimeout = 10 * time.Second
+
+// versionArgs holds the arguments that proji passes to a tool to detect its version, in the order they are tried. Most
+// tools understand --version; others, like go, only understand a version subcommand.
+var versionArgs = [][]string{{"--version"}, {"version"}, {"-version"}}
+
+// versionPattern matches the first version number in the output of a tool, e.g. 2.28.0 in "git version 2.28.0" or
+// 1.20.3 in "go version go1.20.3 linux/amd64".
+var versionPattern = regexp.MustCompile(`\d+(?:\.\d+)+`)
+
+// RequirementCheck is the result of the check of a single requirement of a package.
+type RequirementCheck struct {
+	// Requirement is the requirement as it was written in the package config, e.g. "git >= 2.28".
+	Requirement string
+
+	// Path is the path the tool was found at. It's empty if the tool wasn't found.
+	Path string
+
+	// Command is the command that reported the version of the tool, e.g. "git --version". It's empty if the requirement
+	// has no version or if no command reported a version.
+	Command string
+
+	// Version is the installed version of the tool.
+	Version string
+
+	// Problem describes why the requirement isn't met. It's empty if the requirement is met.
+	Problem string
+}
+
+// checkRequirements checks that all tools that the given package requires are installed in a matching version. If
+// any requirement isn't met, a RequirementsError lists all unmet requirements.
+func checkRequirements(ctx context.Context, pkg *domain.Package) error {
+	if pkg == nil || len(pkg.Requires) == 0 {
+		return nil
+	}
+	var failed []*RequirementCheck
+	for _, requires := range pkg.Requires {
+		check := checkRequirement(ctx, requires)
+		if check.Problem != "" {
+			failed = append(failed, check)
+		}
+	}
+	if len(failed) > 0 {
+		return &RequirementsError{Package: pkg.Label, Failed: failed}
+	}
+	return nil
+}
+
+// checkRequirement checks a single requirement. The tool has to be found in the PATH; its version is detected by
+// running it with each of the version arguments until one of them reports a version.
+func checkRequirement(ctx context.Context, requires string) *RequirementCheck {
+	check := &RequirementCheck{Requirement: requires}
+	requirement, err := domain.ParseRequirement(requires)
+	if err != nil {
+		check.Problem = err.Error()
+		return check
+	}
+	check.Path, err = exec.LookPath(requirement.Tool)
+	if err != nil {
+		check.Path = ""
+		check.Problem = "not found in PATH"
+		return check
+	}
+	if requirement.Operator == "" {
+		return check
+	}
+
+	check.Version, check.Command = detectVersion(ctx, check.Path, requirement.Tool)
+	if check.Version == "" {
+		check.Problem = fmt.Sprintf("version could not be detected at %s", check.Path)
+		return check
+	}
+	if !matchesVersion(check.Version, requirement.Operator, requirement.Version) {
+		check.Problem = fmt.Sprintf("found version %s at %s", check.Version, check.Path)
+	}
+	return check
+}
+
+// detectVersion runs the tool at the given path with each of the version arguments and returns the first version
+// number found in its output, together with the command that reported it.
+func detectVersion(ctx context.Context, path, tool string) (string, string) {
+	for _, args := range versionArgs {
+		versionCtx, cancel := context.WithTimeout(ctx, versionTimeout)
+		output, err := exec.CommandContext(versionCtx, path, args...).CombinedOutput()
+		cancel()
+		if err != nil {
+			continue
+		}
+		version := versionPattern.FindString(string(output))
+		if version != "" {
+			return version, tool + " " + strings.Join(args, " ")
+		}
+	}
+	return "", ""
+}
+
+// matchesVersion reports whether the given version satisfies the given operator and required version. Versions are
+// compared part by part; missing parts count as zero, so 2.28 equals 2.28.0.
+func matchesVersion(version, operator, required string) bool {
+	comparison := compareVersions(version, required)
+	switch operator {
+	case domain.RequirementOperatorEqual:
+		return comparison == 0
+	case domain.RequirementOperatorGreater:
+		return comparison > 0
+	case domain.RequirementOperatorGreaterEqual:
+		return comparison >= 0
+	case domain.RequirementOperatorLess:
+		return comparison < 0
+	case domain.RequirementOperatorLessEqual:
+		return comparison <= 0
+	default:
+		return false
+	}
+}
+
+// compareVersions compares two dotted version numbers. It returns a negative number if a is lower than b, zero if they
+// are equal and a positive number if a is greater than b.
+func compareVersions(a, b string) int {
+	aParts, bParts := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		aPart, bPart := versionPart(aParts, i), versionPart(bParts, i)
+		if aPart != bPart {
+			return aPart - bPart
+		}
+	}
+	return 0
+}
+
+// versionPart returns the numeric part of a version at the given index or zero if the version has no such part.
+func versionPart(parts []string, i int) int {
+	if i >= len(parts) {
+		return 0
+	}
+	n, _ := strconv.Atoi(parts[i])
+	return n
+}
@@ -0,0 +1,102 @@
+package projectservice
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchesVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		operator string
+		required string
+		want     bool
+	}{
+		{version: "2.28.0", operator: domain.RequirementOperatorGreaterEqual, required: "2.28", want: true},
+		{version: "2.30.1", operator: domain.RequirementOperatorGreaterEqual, required: "2.28", want: true},
+		{version: "2.9.5", operator: domain.RequirementOperatorGreaterEqual, required: "2.28", want: false},
+		{version: "1.20", operator: domain.RequirementOperatorEqual, required: "1.20.0", want: true},
+		{version: "1.20.1", operator: domain.RequirementOperatorGreater, required: "1.20", want: true},
+		{version: "3.0", operator: domain.RequirementOperatorLess, required: "3", want: false},
+		{version: "2.99", operator: domain.RequirementOperatorLessEqual, required: "3", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version+tt.operator+tt.required, func(t *testing.T) {
+			assert.Equal(t, tt.want, matchesVersion(tt.version, tt.operator, tt.required))
+		})
+	}
+}
+
+func TestCheckRequirements(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a shell script as fake tool")
+	}
+	binPath, err := ioutil.TempDir("", "proji-requirement-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(binPath)
+
+	// The fake tool only understands the version subcommand, just like go.
+	tool := filepath.Join(binPath, "proji-fake-tool")
+	script := "#!/bin/sh\nif [ \"$1\" = version ]; then echo \"fake version fake2.30.1 linux\"; exit 0; fi\nexit 2\n"
+	err = ioutil.WriteFile(tool, []byte(script), 0o700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+	_ = os.Setenv("PATH", binPath+string(os.PathListSeparator)+path)
+
+	tests := []struct {
+		name     string
+		requires []string
+		want     []*RequirementCheck
+	}{
+		{
+			name:     "Test met requirements",
+			requires: []string{"proji-fake-tool", "proji-fake-tool >= 2.28"},
+		},
+		{
+			name:     "Test unmet requirements",
+			requires: []string{"proji-fake-tool >= 2.31", "proji-missing-tool", "proji-fake-tool"},
+			want: []*RequirementCheck{
+				{
+					Requirement: "proji-fake-tool >= 2.31",
+					Path:        tool,
+					Command:     "proji-fake-tool version",
+					Version:     "2.30.1",
+					Problem:     "found version 2.30.1 at " + tool,
+				},
+				{Requirement: "proji-missing-tool", Problem: "not found in PATH"},
+			},
+		},
+		{
+			name:     "Test invalid requirement",
+			requires: []string{"proji-fake-tool ~> 2"},
+			want:     []*RequirementCheck{{Requirement: "proji-fake-tool ~> 2", Problem: `invalid requirement "proji-fake-tool ~> 2"`}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg := &domain.Package{Label: "tst", Requires: tt.requires}
+			err := checkRequirements(context.Background(), pkg)
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			requirementsErr, ok := err.(*RequirementsError)
+			if !assert.True(t, ok, "checkRequirements() error = %v, want RequirementsError", err) {
+				return
+			}
+			assert.Equal(t, tt.want, requirementsErr.Failed)
+		})
+	}
+}
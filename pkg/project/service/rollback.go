@@ -0,0 +1,155 @@
+package projectservice
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// changeLog records the files and folders that are created, overwritten or removed during the creation or update of a
+// project, so that it can be rolled back if it fails.
+type changeLog struct {
+	created     []string
+	overwritten []*fileBackup
+}
+
+// fileBackup holds the original content of a file that was overwritten or removed.
+type fileBackup struct {
+	path    string
+	content []byte
+	mode    os.FileMode
+}
+
+// mkdir creates a single folder and records it.
+func (cl *changeLog) mkdir(path string) error {
+	err := os.Mkdir(path, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	cl.created = append(cl.created, path)
+	return nil
+}
+
+// mkdirAll creates a folder along with all missing parents and records every folder it created.
+func (cl *changeLog) mkdirAll(path string) error {
+	// Collect the missing folders, starting with the deepest one.
+	var missing []string
+	for current := filepath.Clean(path); ; current = filepath.Dir(current) {
+		if _, err := os.Stat(current); err == nil {
+			break
+		}
+		missing = append(missing, current)
+		if filepath.Dir(current) == current {
+			break
+		}
+	}
+
+	err := os.MkdirAll(path, os.ModePerm)
+	for i := len(missing) - 1; i >= 0; i-- {
+		if _, statErr := os.Stat(missing[i]); statErr == nil {
+			cl.created = append(cl.created, missing[i])
+		}
+	}
+	return err
+}
+
+// createFile creates an empty file and its parent folders. Existing files are left untouched.
+func (cl *changeLog) createFile(path string) error {
+	err := cl.mkdirAll(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o666)
+	if os.IsExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	cl.created = append(cl.created, path)
+	return file.Close()
+}
+
+// writeFile writes a file and creates its parent folders. If the file already exists, its original content is backed
+// up first.
+func (cl *changeLog) writeFile(path string, content []byte, perm os.FileMode) error {
+	err := cl.mkdirAll(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	switch {
+	case err == nil:
+		original, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		cl.overwritten = append(cl.overwritten, &fileBackup{path: path, content: original, mode: info.Mode().Perm()})
+	case os.IsNotExist(err):
+		cl.created = append(cl.created, path)
+	default:
+		return err
+	}
+	return ioutil.WriteFile(path, content, perm)
+}
+
+// removeFile removes a file after backing up its content, so that the rollback restores it.
+func (cl *changeLog) removeFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if err != nil {
+		return err
+	}
+	cl.overwritten = append(cl.overwritten, &fileBackup{path: path, content: content, mode: info.Mode().Perm()})
+	return nil
+}
+
+// hasCreated reports whether the given path was created by the change log.
+func (cl *changeLog) hasCreated(path string) bool {
+	for _, created := range cl.created {
+		if created == path {
+			return true
+		}
+	}
+	return false
+}
+
+// hasWritten reports whether the given file was created or overwritten by the change log.
+func (cl *changeLog) hasWritten(path string) bool {
+	for _, backup := range cl.overwritten {
+		if backup.path == path {
+			return true
+		}
+	}
+	return cl.hasCreated(path)
+}
+
+// rollback restores all overwritten and removed files and removes everything that was created, in reverse order. Removing a
+// created folder removes everything inside of it, including files that plugins created.
+func (cl *changeLog) rollback() error {
+	var firstErr error
+	for i := len(cl.overwritten) - 1; i >= 0; i-- {
+		backup := cl.overwritten[i]
+		err := ioutil.WriteFile(backup.path, backup.content, backup.mode)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	for i := len(cl.created) - 1; i >= 0; i-- {
+		err := os.RemoveAll(cl.created[i])
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	cl.created = nil
+	cl.overwritten = nil
+	return firstErr
+}
@@ -0,0 +1,232 @@
+package projectservice
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nikoksr/proji/internal/config"
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeLogRollback(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "proji-rollback-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	existingPath := filepath.Join(tempDir, "existing.txt")
+	_ = ioutil.WriteFile(existingPath, []byte("original"), 0o640)
+
+	changes := &changeLog{}
+	assert.NoError(t, changes.mkdirAll(filepath.Join(tempDir, "a", "b")))
+	assert.NoError(t, changes.writeFile(filepath.Join(tempDir, "c", "new.txt"), []byte("new"), 0o600))
+	assert.NoError(t, changes.createFile(filepath.Join(tempDir, "a", "empty.txt")))
+	assert.NoError(t, changes.writeFile(existingPath, []byte("changed"), 0o600))
+	assert.True(t, changes.hasCreated(filepath.Join(tempDir, "c", "new.txt")))
+	assert.False(t, changes.hasCreated(existingPath))
+
+	assert.NoError(t, changes.rollback())
+	assert.NoDirExists(t, filepath.Join(tempDir, "a"))
+	assert.NoDirExists(t, filepath.Join(tempDir, "c"))
+	assert.DirExists(t, tempDir)
+	content, err := ioutil.ReadFile(existingPath)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "original", string(content))
+	}
+	info, err := os.Stat(existingPath)
+	if assert.NoError(t, err) {
+		assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+	}
+}
+
+func TestCreateProjectRollback(t *testing.T) {
+	configRootPath, err := ioutil.TempDir("", "proji-rollback-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(configRootPath)
+
+	writeTestFiles(t, configRootPath, map[string]string{
+		"templates/readme.md": "# package readme\n",
+		"plugins/ok.lua":      "proji.variables.ok = 'true'\n",
+		"plugins/fail.lua":    "error('plugin failed')\n",
+		"plugins/git.sh":      "#!/bin/sh\nmkdir -p .git\necho '[core]' > .git/config\necho changed > notes.txt\nexit 1\n",
+	})
+	err = os.Chmod(filepath.Join(configRootPath, "plugins", "git.sh"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newPackage := func(plugin string) *domain.Package {
+		pkg := domain.NewPackage("test", "tst")
+		pkg.Templates = []*domain.Template{
+			{IsFile: true, Destination: "README.md", Path: "readme.md"},
+			{IsFile: false, Destination: "docs/api"},
+		}
+		pkg.Plugins = []*domain.Plugin{{Path: plugin, ExecNumber: 1}}
+		return pkg
+	}
+
+	tests := []struct {
+		name     string
+		plugin   string
+		existing bool
+		storeErr error
+		wantErr  bool
+	}{
+		{name: "Test success", plugin: "ok.lua"},
+		{name: "Test failing plugin", plugin: "fail.lua", wantErr: true},
+		{name: "Test failing store", plugin: "ok.lua", storeErr: errors.New("database is locked"), wantErr: true},
+		{name: "Test overwrite with failing plugin", plugin: "fail.lua", existing: true, wantErr: true},
+		{name: "Test overwrite with failing store", plugin: "ok.lua", existing: true, storeErr: errors.New("database is locked"), wantErr: true},
+		{name: "Test existing folder with failing plugin that changes files", plugin: "git.sh", existing: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectsPath, err := ioutil.TempDir("", "proji-rollback-test-")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(projectsPath)
+			projectPath := filepath.Join(projectsPath, "example")
+			options := &domain.CreateOptions{}
+			if tt.existing {
+				writeTestFiles(t, projectPath, map[string]string{"README.md": "# my readme\n", "notes.txt": "notes\n"})
+				options.Existing = true
+				options.MergeStrategy = domain.MergeStrategyOverwrite
+			}
+
+			store := &projectStoreStub{err: tt.storeErr}
+			ps := projectService{templateSettings: &config.Templates{}, projectStore: store}
+			project := domain.NewProject("example", projectPath, newPackage(tt.plugin))
+			err = ps.CreateProject(context.Background(), configRootPath, project, options)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				assert.Equal(t, []*domain.Project{project}, store.stored)
+				assert.FileExists(t, filepath.Join(projectPath, "README.md"))
+				assert.DirExists(t, filepath.Join(projectPath, "docs", "api"))
+				return
+			}
+
+			if !assert.Error(t, err) {
+				return
+			}
+			assert.Empty(t, store.stored)
+			if !tt.existing {
+				assert.NoDirExists(t, projectPath)
+				return
+			}
+
+			// Whatever plugins did inside of the existing folder is kept and the rollback is reported as partial.
+			assert.Contains(t, err.Error(), "rolled back partially")
+
+			// The existing folder is kept, overwritten files are restored and created ones are removed.
+			assertFileContent(t, filepath.Join(projectPath, "README.md"), "# my readme\n")
+			assert.NoDirExists(t, filepath.Join(projectPath, "docs"))
+			if tt.plugin == "git.sh" {
+				assert.FileExists(t, filepath.Join(projectPath, ".git", "config"))
+				assertFileContent(t, filepath.Join(projectPath, "notes.txt"), "changed\n")
+			}
+		})
+	}
+}
+
+func TestCreateProjectKeepOnFailure(t *testing.T) {
+	configRootPath, err := ioutil.TempDir("", "proji-rollback-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(configRootPath)
+	writeTestFiles(t, configRootPath, map[string]string{"plugins/fail.lua": "error('plugin failed')\n"})
+
+	tests := []struct {
+		name           string
+		existingFiles  map[string]string
+		wantManifest   bool
+		wantErrMessage string
+	}{
+		{name: "Test manifest of kept project", wantManifest: true, wantErrMessage: "plugin failed"},
+		{
+			name:           "Test unwritable manifest",
+			existingFiles:  map[string]string{".proji": "not a folder\n"},
+			wantErrMessage: "failed to write manifest",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectsPath, err := ioutil.TempDir("", "proji-rollback-test-")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(projectsPath)
+			projectPath := filepath.Join(projectsPath, "example")
+			options := &domain.CreateOptions{KeepOnFailure: true, WriteManifest: true}
+			if tt.existingFiles != nil {
+				writeTestFiles(t, projectPath, tt.existingFiles)
+				options.Existing = true
+				options.MergeStrategy = domain.MergeStrategySkip
+			}
+
+			pkg := domain.NewPackage("test", "tst")
+			pkg.Templates = []*domain.Template{{IsFile: false, Destination: "docs"}}
+			pkg.Plugins = []*domain.Plugin{{Path: "fail.lua", ExecNumber: 1}}
+			store := &projectStoreStub{}
+			ps := projectService{templateSettings: &config.Templates{}, projectStore: store}
+			err = ps.CreateProject(context.Background(), configRootPath, domain.NewProject("example", projectPath, pkg), options)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.wantErrMessage)
+			}
+			assert.Empty(t, store.stored)
+
+			// The project is kept even though it failed.
+			assert.DirExists(t, filepath.Join(projectPath, "docs"))
+			if tt.wantManifest {
+				assert.FileExists(t, manifestPath(projectPath))
+			}
+		})
+	}
+}
+
+func TestCreateProjectReplace(t *testing.T) {
+	configRootPath, err := ioutil.TempDir("", "proji-rollback-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(configRootPath)
+	writeTestFiles(t, configRootPath, map[string]string{
+		"plugins/ok.lua":   "proji.variables.ok = 'true'\n",
+		"plugins/fail.lua": "error('plugin failed')\n",
+	})
+
+	for _, plugin := range []string{"ok.lua", "fail.lua"} {
+		t.Run(plugin, func(t *testing.T) {
+			projectsPath, err := ioutil.TempDir("", "proji-rollback-test-")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(projectsPath)
+
+			pkg := domain.NewPackage("test", "tst")
+			pkg.Plugins = []*domain.Plugin{{Path: plugin, ExecNumber: 1}}
+			project := domain.NewProject("example", filepath.Join(projectsPath, "example"), pkg)
+			store := &projectStoreStub{}
+			ps := projectService{templateSettings: &config.Templates{}, projectStore: store}
+			err = ps.CreateProject(context.Background(), configRootPath, project, &domain.CreateOptions{Replace: true})
+
+			// The stored project is only replaced once the creation succeeded.
+			assert.Empty(t, store.stored)
+			if plugin == "fail.lua" {
+				assert.Error(t, err)
+				assert.Empty(t, store.replaced)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, []*domain.Project{project}, store.replaced)
+		})
+	}
+}
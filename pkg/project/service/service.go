@@ -1,16 +1,21 @@
 package projectservice
 
 import (
+	"github.com/nikoksr/proji/internal/config"
 	"github.com/nikoksr/proji/pkg/domain"
 )
 
 type projectService struct {
-	projectStore domain.ProjectStore
+	pluginSettings   *config.Plugins
+	templateSettings *config.Templates
+	projectStore     domain.ProjectStore
 }
 
-func New(store domain.ProjectStore) domain.ProjectService {
+func New(pluginSettings *config.Plugins, templateSettings *config.Templates, store domain.ProjectStore) domain.ProjectService {
 	return &projectService{
-		projectStore: store,
+		pluginSettings:   pluginSettings,
+		templateSettings: templateSettings,
+		projectStore:     store,
 	}
 }
 
@@ -27,7 +32,7 @@ func (ps projectService) LoadProjectList(paths ...string) ([]*domain.Project, er
 }
 
 func (ps projectService) UpdateProjectLocation(oldPath, newPath string) error {
-	return ps.UpdateProjectLocation(oldPath, newPath)
+	return ps.projectStore.UpdateProjectLocation(oldPath, newPath)
 }
 
 func (ps projectService) RemoveProject(path string) error {
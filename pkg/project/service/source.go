@@ -0,0 +1,209 @@
+package projectservice
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/pkg/errors"
+)
+
+// templateCacheFolder is the folder inside of proji's config folder that remote templates are cached in.
+const templateCacheFolder = "cache/templates"
+
+// fetchRemoteTemplates fetches the remote templates of the given operations and points their sources at the cached
+// files. Templates with a checksum that were fetched before are taken from the cache.
+func fetchRemoteTemplates(ctx context.Context, configRootPath string, operations []*domain.Operation) error {
+	for _, operation := range operations {
+		if operation.Template == nil {
+			continue
+		}
+		path, err := fetchTemplate(ctx, configRootPath, operation.Template)
+		if err != nil {
+			return errors.Wrapf(err, "fetch template %s", operation.Template.Path)
+		}
+		operation.Source = path
+	}
+	return nil
+}
+
+// fetchTemplate returns the path of the cached file of a remote template. A template with a checksum is cached per
+// checksum and only fetched if no cached file matches it, so changing the checksum fetches the template again. A
+// template without a checksum may change at any time, so it's fetched every time. Fetched files have to match the
+// template's checksum.
+func fetchTemplate(ctx context.Context, configRootPath string, template *domain.Template) (string, error) {
+	cacheFolder := filepath.Join(configRootPath, filepath.FromSlash(templateCacheFolder))
+	key := template.Path
+	if template.Checksum != "" {
+		key += "\x00" + normalizeChecksum(template.Checksum)
+	}
+	path := filepath.Join(cacheFolder, fmt.Sprintf("%x", sha256.Sum256([]byte(key))))
+
+	if template.Checksum != "" {
+		content, err := ioutil.ReadFile(path)
+		if err == nil && verifyChecksum(content, template.Checksum) == nil {
+			return path, nil
+		}
+	}
+
+	var content []byte
+	var err error
+	if strings.HasPrefix(template.Path, domain.TemplateSourceGit) {
+		content, err = fetchGitFile(ctx, strings.TrimPrefix(template.Path, domain.TemplateSourceGit))
+	} else {
+		content, err = fetchHTTPSFile(ctx, template.Path)
+	}
+	if err != nil {
+		return "", err
+	}
+	err = verifyChecksum(content, template.Checksum)
+	if err != nil {
+		return "", err
+	}
+
+	// Write to a temporary file first, so that parallel creations never read a partially written cache entry.
+	err = os.MkdirAll(cacheFolder, os.ModePerm)
+	if err != nil {
+		return "", err
+	}
+	file, err := ioutil.TempFile(cacheFolder, "fetch-")
+	if err != nil {
+		return "", err
+	}
+	_, err = file.Write(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return "", err
+	}
+	return path, nil
+}
+
+// httpClient downloads remote templates and the files of download steps. Its timeout covers the whole download, so that
+// an unresponsive server can't stall the creation of a project.
+var httpClient = &http.Client{Timeout: 5 * time.Minute}
+
+// fetchHTTPSFile downloads the file at the given URL.
+func fetchHTTPSFile(ctx context.Context, url string) ([]byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download failed with status %s", response.Status)
+	}
+	return ioutil.ReadAll(response.Body)
+}
+
+// fetchGitFile reads a file from a shallow clone of a git repository. The source has the form REPOSITORY//PATH with an
+// optional ref query parameter, e.g. https://github.com/org/repo.git//.golangci.yml?ref=v1.0.0.
+func fetchGitFile(ctx context.Context, source string) ([]byte, error) {
+	repository, path, ref, err := parseGitSource(source)
+	if err != nil {
+		return nil, err
+	}
+	folder, err := ioutil.TempDir("", "proji-template-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(folder)
+
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	args = append(args, "--", repository, folder)
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err = cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("git clone failed: %v: %s", err, strings.TrimSpace(output.String()))
+	}
+
+	// The path was checked when it was parsed, but the repository may still contain symlinks that point outside of it.
+	root, err := filepath.EvalSymlinks(folder)
+	if err != nil {
+		return nil, err
+	}
+	filePath, err := filepath.EvalSymlinks(filepath.Join(root, filepath.FromSlash(path)))
+	if err != nil {
+		return nil, err
+	}
+	if !isInsideFolder(root, filePath) {
+		return nil, fmt.Errorf("path %s is outside of the repository", path)
+	}
+	return ioutil.ReadFile(filePath)
+}
+
+// isInsideFolder reports whether the given path is the given folder or inside of it. Both paths have to be clean.
+func isInsideFolder(folder, path string) bool {
+	relPath, err := filepath.Rel(folder, path)
+	return err == nil && relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator))
+}
+
+// parseGitSource splits the source of a git template into the URL of the repository, the path of the file inside of
+// the repository and the ref.
+func parseGitSource(source string) (repository, path, ref string, err error) {
+	u, err := url.Parse(source)
+	if err != nil {
+		return "", "", "", err
+	}
+	ref = u.Query().Get("ref")
+	u.RawQuery = ""
+	separator := strings.Index(u.Path, "//")
+	if separator < 0 {
+		return "", "", "", fmt.Errorf("git source %s has no file path, e.g. REPOSITORY//PATH", source)
+	}
+	path = strings.Trim(u.Path[separator+2:], "/")
+	if path == "" {
+		return "", "", "", fmt.Errorf("git source %s has an empty file path", source)
+	}
+	for _, name := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if name == ".." {
+			return "", "", "", fmt.Errorf("git source %s has a file path outside of the repository", source)
+		}
+	}
+	u.Path = u.Path[:separator]
+	return u.String(), path, ref, nil
+}
+
+// verifyChecksum checks the given content against a sha256 checksum. The checksum may be prefixed with "sha256:". An
+// empty checksum accepts any content.
+func verifyChecksum(content []byte, checksum string) error {
+	if checksum == "" {
+		return nil
+	}
+	want := normalizeChecksum(checksum)
+	got := fmt.Sprintf("%x", sha256.Sum256(content))
+	if got != want {
+		return fmt.Errorf("checksum mismatch, expected sha256:%s, got sha256:%s", want, got)
+	}
+	return nil
+}
+
+// normalizeChecksum returns the hex digest of a sha256 checksum in lower case, without the "sha256:" prefix.
+func normalizeChecksum(checksum string) string {
+	return strings.ToLower(strings.TrimPrefix(checksum, "sha256:"))
+}
@@ -0,0 +1,157 @@
+package projectservice
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGitSource(t *testing.T) {
+	tests := []struct {
+		source         string
+		wantRepository string
+		wantPath       string
+		wantRef        string
+		wantErr        bool
+	}{
+		{
+			source:         "https://github.com/org/repo.git//.golangci.yml?ref=v1.0.0",
+			wantRepository: "https://github.com/org/repo.git",
+			wantPath:       ".golangci.yml",
+			wantRef:        "v1.0.0",
+		},
+		{
+			source:         "ssh://git@github.com/org/repo.git//licenses/MIT",
+			wantRepository: "ssh://git@github.com/org/repo.git",
+			wantPath:       "licenses/MIT",
+		},
+		{source: "https://github.com/org/repo.git", wantErr: true},
+		{source: "https://github.com/org/repo.git//", wantErr: true},
+		{source: "https://github.com/org/repo.git//../../etc/passwd", wantErr: true},
+		{source: "file:///tmp/repo//docs/../../secret", wantErr: true},
+	}
+	for _, tt := range tests {
+		repository, path, ref, err := parseGitSource(tt.source)
+		if tt.wantErr {
+			assert.Error(t, err, tt.source)
+			continue
+		}
+		assert.NoError(t, err, tt.source)
+		assert.Equal(t, tt.wantRepository, repository)
+		assert.Equal(t, tt.wantPath, path)
+		assert.Equal(t, tt.wantRef, ref)
+	}
+}
+
+func TestFetchTemplate(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	tmpDir, err := ioutil.TempDir("", "proji-source-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Create a repository with a single file and a symlink that points outside of the repository.
+	repository := filepath.Join(tmpDir, "repo")
+	_ = os.MkdirAll(repository, os.ModePerm)
+	_ = ioutil.WriteFile(filepath.Join(tmpDir, "secret"), []byte("secret\n"), 0o600)
+	symlinkErr := os.Symlink(filepath.Join(tmpDir, "secret"), filepath.Join(repository, "link"))
+	commitFile := func(content string) {
+		_ = ioutil.WriteFile(filepath.Join(repository, "LICENSE"), []byte(content), 0o600)
+		for _, args := range [][]string{
+			{"add", "."},
+			{"-c", "user.name=proji", "-c", "user.email=proji@example.com", "commit", "--quiet", "-m", "update"},
+		} {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = repository
+			if output, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v: %v: %s", args, err, output)
+			}
+		}
+	}
+	cmd := exec.Command("git", "init", "--quiet")
+	cmd.Dir = repository
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, output)
+	}
+	commitFile("MIT {{name}}\n")
+
+	configRootPath := filepath.Join(tmpDir, "config")
+	source := domain.TemplateSourceGit + "file://" + filepath.ToSlash(repository)
+	template := &domain.Template{
+		IsFile:   true,
+		Path:     source + "//LICENSE",
+		Checksum: "sha256:f1c8a1bb8e9f0e6b0c8ab5e0dba4bb7ee1a4a1d3e2d2f0c4fbb6f0b7c8e1d5b1",
+	}
+	readTemplate := func() string {
+		path, err := fetchTemplate(context.Background(), configRootPath, template)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		content, err := ioutil.ReadFile(path)
+		assert.NoError(t, err)
+		return string(content)
+	}
+
+	// A wrong checksum rejects the template and doesn't cache it.
+	_, err = fetchTemplate(context.Background(), configRootPath, template)
+	assert.Error(t, err)
+
+	// Templates without a checksum are fetched every time.
+	template.Checksum = ""
+	assert.Equal(t, "MIT {{name}}\n", readTemplate())
+	commitFile("MIT 2 {{name}}\n")
+	assert.Equal(t, "MIT 2 {{name}}\n", readTemplate())
+
+	// Symlinks that point outside of the repository are rejected.
+	if symlinkErr == nil {
+		_, err = fetchTemplate(context.Background(), configRootPath, &domain.Template{IsFile: true, Path: source + "//link"})
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "outside of the repository")
+		}
+	}
+
+	// Templates with a checksum are cached per checksum. Once cached, the repository isn't needed anymore.
+	template.Checksum = fmt.Sprintf("sha256:%x", sha256.Sum256([]byte("MIT 2 {{name}}\n")))
+	assert.Equal(t, "MIT 2 {{name}}\n", readTemplate())
+	_ = os.RemoveAll(filepath.Join(repository, ".git"))
+	assert.Equal(t, "MIT 2 {{name}}\n", readTemplate())
+}
+
+func TestFetchHTTPSFileTimeout(t *testing.T) {
+	// The server sends the headers, but never the body.
+	done := make(chan struct{})
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	original := httpClient
+	defer func() { httpClient = original }()
+	httpClient = server.Client()
+	httpClient.Timeout = 100 * time.Millisecond
+
+	started := time.Now()
+	_, err := fetchHTTPSFile(context.Background(), server.URL+"/LICENSE")
+	assert.Error(t, err)
+	assert.Less(t, int64(time.Since(started)), int64(3*time.Second), "download wasn't stopped")
+}
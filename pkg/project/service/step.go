@@ -0,0 +1,194 @@
+package projectservice
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/pkg/errors"
+)
+
+// runStep runs a built-in step of the project's package. Like a plugin, the step runs inside of the project's folder,
+// its output is written to the plugin log and it gets canceled if it exceeds its timeout. Files and folders that the
+// step creates are recorded in the given change log, so that a failed creation removes them again. Paths, arguments
+// and environment variables of the step are rendered with the given variables.
+func (pr *pluginRunner) runStep(
+	ctx context.Context, step *domain.Step, changes *changeLog, variables domain.Variables,
+) error {
+	name := render(step.Name(), variables)
+	task := &runnerTask{kind: "step", name: name, timeout: step.Timeout}
+	output, timeout, err := pr.runTask(ctx, task, func(ctx context.Context, output io.Writer) (*pluginResult, error) {
+		return nil, executeStep(ctx, pr.context, step, changes, variables, output)
+	})
+	if err != nil {
+		return &StepError{
+			Name:    name,
+			Phase:   phaseName(pr.context.Phase),
+			Timeout: timeout,
+			Output:  output,
+			LogPath: pr.logPath(),
+			Err:     err,
+		}
+	}
+	return nil
+}
+
+// executeStep executes a step based on its type.
+func executeStep(
+	ctx context.Context,
+	pc *pluginContext,
+	step *domain.Step,
+	changes *changeLog,
+	variables domain.Variables,
+	output io.Writer,
+) error {
+	projectPath := pc.Project.Path
+	switch step.Type {
+	case domain.StepTypeRun:
+		dir, err := stepPath(projectPath, render(step.Dir, variables))
+		if err != nil {
+			return err
+		}
+		args := make([]string, 0, len(step.Args))
+		for _, arg := range step.Args {
+			args = append(args, render(arg, variables))
+		}
+		cmd := exec.CommandContext(ctx, render(step.Command, variables), args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), pc.environment()...)
+		for key, value := range step.Env {
+			cmd.Env = append(cmd.Env, key+"="+render(value, variables))
+		}
+		return runCommand(ctx, cmd, output, output)
+
+	case domain.StepTypeGitInit:
+		dir, err := stepPath(projectPath, render(step.Dir, variables))
+		if err != nil {
+			return err
+		}
+		message := step.Message
+		if message == "" {
+			message = domain.DefaultCommitMessage
+		}
+		env := append(os.Environ(), pc.environment()...)
+		commitArgs := append(
+			gitIdentityArgs(ctx, dir, env),
+			"commit", "--allow-empty", "--message", render(message, variables),
+		)
+		for _, args := range [][]string{
+			{"init"},
+			{"add", "--all"},
+			commitArgs,
+		} {
+			cmd := exec.CommandContext(ctx, "git", args...)
+			cmd.Dir = dir
+			cmd.Env = env
+			err = runCommand(ctx, cmd, output, output)
+			if err != nil {
+				return errors.Wrapf(err, "git %s", gitSubcommand(args))
+			}
+		}
+		return nil
+
+	case domain.StepTypeMkdir:
+		path, err := stepPath(projectPath, render(step.Path, variables))
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(output, "creating folder %s\n", path)
+		return changes.mkdirAll(path)
+
+	case domain.StepTypeDownload:
+		path, err := stepPath(projectPath, render(step.Path, variables))
+		if err != nil {
+			return err
+		}
+		url := render(step.URL, variables)
+		_, _ = fmt.Fprintf(output, "downloading %s to %s\n", url, path)
+		content, err := fetchHTTPSFile(ctx, url)
+		if err != nil {
+			return err
+		}
+		err = verifyChecksum(content, step.Checksum)
+		if err != nil {
+			return err
+		}
+		err = changes.mkdirAll(filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		return changes.writeFile(path, content, 0o666)
+
+	case domain.StepTypeChmod:
+		path, err := stepPath(projectPath, render(step.Path, variables))
+		if err != nil {
+			return err
+		}
+		mode, err := strconv.ParseUint(step.Mode, 8, 32)
+		if err != nil {
+			return fmt.Errorf("invalid mode %s", step.Mode)
+		}
+		_, _ = fmt.Fprintf(output, "changing mode of %s to %s\n", path, step.Mode)
+		return os.Chmod(path, os.FileMode(mode))
+
+	default:
+		return fmt.Errorf("step type %s not supported", step.Type)
+	}
+}
+
+// Default identity of the initial commit of a git-init step. It's only used if git has no user name or email
+// configured, so that the step doesn't fail on fresh machines and in CI.
+const (
+	defaultGitUserName  = "proji"
+	defaultGitUserEmail = "proji@localhost"
+)
+
+// gitIdentityArgs returns the git options that set the default identity for every part of the identity that isn't
+// configured for the repository in the given folder.
+func gitIdentityArgs(ctx context.Context, dir string, env []string) []string {
+	var args []string
+	for _, option := range [][2]string{{"user.name", defaultGitUserName}, {"user.email", defaultGitUserEmail}} {
+		key, value := option[0], option[1]
+		cmd := exec.CommandContext(ctx, "git", "config", "--get", key)
+		cmd.Dir = dir
+		cmd.Env = env
+		configured, err := cmd.Output()
+		if err == nil && strings.TrimSpace(string(configured)) != "" {
+			continue
+		}
+		args = append(args, "-c", key+"="+value)
+	}
+	return args
+}
+
+// gitSubcommand returns the subcommand of the given git arguments, skipping leading options.
+func gitSubcommand(args []string) string {
+	for i := 0; i < len(args); i++ {
+		if args[i] == "-c" {
+			i++
+			continue
+		}
+		return args[i]
+	}
+	return ""
+}
+
+// stepPath returns the absolute path of a file or folder of a step. Paths are relative to the project's root folder
+// and may not leave it. An empty path is the project's root folder.
+func stepPath(projectPath, path string) (string, error) {
+	if filepath.IsAbs(path) {
+		return "", fmt.Errorf("path %s has to be relative to the project", path)
+	}
+	absPath := filepath.Join(projectPath, path)
+	relPath, err := filepath.Rel(projectPath, absPath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %s is outside of the project", path)
+	}
+	return absPath, nil
+}
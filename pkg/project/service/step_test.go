@@ -0,0 +1,262 @@
+package projectservice
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepPath(t *testing.T) {
+	projectPath := filepath.Join(os.TempDir(), "example")
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{name: "Test empty path", path: "", want: projectPath},
+		{name: "Test relative path", path: "bin/tools", want: filepath.Join(projectPath, "bin", "tools")},
+		{name: "Test path inside of the project", path: "bin/../docs", want: filepath.Join(projectPath, "docs")},
+		{name: "Test absolute path", path: filepath.Join(projectPath, "bin"), wantErr: true},
+		{name: "Test path outside of the project", path: "../other", wantErr: true},
+		{name: "Test parent folder", path: "..", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := stepPath(projectPath, tt.path)
+			assert.Equal(t, tt.wantErr, err != nil, "stepPath() error = %v, wantErr %v", err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExecuteStep(t *testing.T) {
+	projectPath, err := ioutil.TempDir("", "proji-step-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(projectPath)
+	_ = ioutil.WriteFile(filepath.Join(projectPath, "run.sh"), []byte(""), 0o600)
+
+	pc := newPluginContext("", &domain.Project{Name: "example", Path: projectPath}, nil)
+	variables := domain.Variables{"slug": "example"}
+
+	tests := []struct {
+		name    string
+		step    *domain.Step
+		check   func(t *testing.T)
+		wantErr bool
+	}{
+		{
+			name: "Test mkdir",
+			step: &domain.Step{Type: domain.StepTypeMkdir, Path: "bin/{{ slug }}"},
+			check: func(t *testing.T) {
+				info, err := os.Stat(filepath.Join(projectPath, "bin", "example"))
+				assert.NoError(t, err)
+				assert.True(t, info.IsDir())
+			},
+		},
+		{
+			name: "Test chmod",
+			step: &domain.Step{Type: domain.StepTypeChmod, Path: "run.sh", Mode: "0755"},
+			check: func(t *testing.T) {
+				if runtime.GOOS == "windows" {
+					return
+				}
+				info, err := os.Stat(filepath.Join(projectPath, "run.sh"))
+				assert.NoError(t, err)
+				assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+			},
+		},
+		{
+			name:    "Test chmod with invalid mode",
+			step:    &domain.Step{Type: domain.StepTypeChmod, Path: "run.sh", Mode: "rwx"},
+			wantErr: true,
+		},
+		{
+			name:    "Test mkdir outside of the project",
+			step:    &domain.Step{Type: domain.StepTypeMkdir, Path: "../outside"},
+			wantErr: true,
+		},
+		{
+			name:    "Test unknown type",
+			step:    &domain.Step{Type: "copy"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			err := executeStep(context.Background(), pc, tt.step, &changeLog{}, variables, &output)
+			assert.Equal(t, tt.wantErr, err != nil, "executeStep() error = %v, wantErr %v", err, tt.wantErr)
+			if tt.check != nil {
+				tt.check(t)
+			}
+		})
+	}
+}
+
+func TestExecuteRunStep(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("run steps are tested with sh")
+	}
+	projectPath, err := ioutil.TempDir("", "proji-step-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(projectPath)
+	_ = os.Mkdir(filepath.Join(projectPath, "docs"), os.ModePerm)
+
+	pc := newPluginContext("", &domain.Project{Name: "example", Path: projectPath}, nil)
+	variables := domain.Variables{"slug": "example"}
+
+	tests := []struct {
+		name       string
+		step       *domain.Step
+		wantOutput string
+		wantErr    bool
+	}{
+		{
+			name: "Test arguments and environment",
+			step: &domain.Step{
+				Type:    domain.StepTypeRun,
+				Command: "sh",
+				Args:    domain.StringList{"-c", `echo "$1 $GREETING $PROJI_PROJECT_NAME"`, "sh", "{{ slug }}"},
+				Env:     domain.Variables{"GREETING": "hello {{ slug }}"},
+			},
+			wantOutput: "example hello example example\n",
+		},
+		{
+			name:       "Test working directory",
+			step:       &domain.Step{Type: domain.StepTypeRun, Command: "sh", Args: domain.StringList{"-c", "basename $PWD"}, Dir: "docs"},
+			wantOutput: "docs\n",
+		},
+		{
+			name:    "Test failing command",
+			step:    &domain.Step{Type: domain.StepTypeRun, Command: "sh", Args: domain.StringList{"-c", "exit 3"}},
+			wantErr: true,
+		},
+		{
+			name:    "Test directory outside of the project",
+			step:    &domain.Step{Type: domain.StepTypeRun, Command: "sh", Args: domain.StringList{"-c", "true"}, Dir: ".."},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			err := executeStep(context.Background(), pc, tt.step, &changeLog{}, variables, &output)
+			assert.Equal(t, tt.wantErr, err != nil, "executeStep() error = %v, wantErr %v", err, tt.wantErr)
+			if !tt.wantErr {
+				assert.Equal(t, tt.wantOutput, output.String())
+			}
+		})
+	}
+}
+
+func TestExecuteGitInitStep(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	tmpDir, err := ioutil.TempDir("", "proji-step-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Isolate git from the user's configuration, so that no identity is configured.
+	for key, value := range map[string]string{
+		"HOME":                tmpDir,
+		"XDG_CONFIG_HOME":     filepath.Join(tmpDir, "config"),
+		"GIT_CONFIG_NOSYSTEM": "1",
+		"GIT_AUTHOR_NAME":     "",
+		"GIT_AUTHOR_EMAIL":    "",
+		"GIT_COMMITTER_NAME":  "",
+		"GIT_COMMITTER_EMAIL": "",
+		"EMAIL":               "",
+	} {
+		original, ok := os.LookupEnv(key)
+		if value == "" {
+			_ = os.Unsetenv(key)
+		} else {
+			_ = os.Setenv(key, value)
+		}
+		defer func(key string) {
+			if ok {
+				_ = os.Setenv(key, original)
+			} else {
+				_ = os.Unsetenv(key)
+			}
+		}(key)
+	}
+
+	git := func(dir string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, output)
+		}
+		return strings.TrimSpace(string(output))
+	}
+
+	tests := []struct {
+		name       string
+		step       *domain.Step
+		gitConfig  map[string]string
+		wantAuthor string
+		wantBody   string
+	}{
+		{
+			name:       "Test default identity",
+			step:       &domain.Step{Type: domain.StepTypeGitInit},
+			wantAuthor: "proji <proji@localhost>",
+			wantBody:   domain.DefaultCommitMessage,
+		},
+		{
+			name:       "Test configured identity",
+			step:       &domain.Step{Type: domain.StepTypeGitInit, Message: "init {{ slug }}"},
+			gitConfig:  map[string]string{"user.name": "Jane Doe", "user.email": "jane@example.com"},
+			wantAuthor: "Jane Doe <jane@example.com>",
+			wantBody:   "init example",
+		},
+		{
+			name:       "Test partially configured identity",
+			step:       &domain.Step{Type: domain.StepTypeGitInit},
+			gitConfig:  map[string]string{"user.name": "Jane Doe"},
+			wantAuthor: "Jane Doe <proji@localhost>",
+			wantBody:   domain.DefaultCommitMessage,
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = os.Remove(filepath.Join(tmpDir, ".gitconfig"))
+			for key, value := range tt.gitConfig {
+				git(tmpDir, "config", "--global", key, value)
+			}
+			projectPath := filepath.Join(tmpDir, "project"+strconv.Itoa(i))
+			_ = os.Mkdir(projectPath, os.ModePerm)
+			_ = ioutil.WriteFile(filepath.Join(projectPath, "README.md"), []byte("# example\n"), 0o600)
+
+			pc := newPluginContext("", &domain.Project{Name: "example", Path: projectPath}, nil)
+			var output bytes.Buffer
+			err := executeStep(context.Background(), pc, tt.step, &changeLog{}, domain.Variables{"slug": "example"}, &output)
+			if !assert.NoError(t, err, output.String()) {
+				return
+			}
+			assert.Equal(t, tt.wantAuthor, git(projectPath, "log", "-1", "--format=%an <%ae>"))
+			assert.Equal(t, tt.wantBody, git(projectPath, "log", "-1", "--format=%s"))
+			assert.Equal(t, "README.md", git(projectPath, "ls-files"))
+		})
+	}
+}
@@ -0,0 +1,314 @@
+package projectservice
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/nikoksr/proji/internal/config"
+	"github.com/nikoksr/proji/internal/diff"
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/pkg/errors"
+)
+
+// Labels of the conflict markers that an update writes into files that both the user and the package changed.
+const (
+	conflictLabelCurrent = "current"
+	conflictLabelPackage = "package"
+)
+
+// UpdateProject re-renders the templates of the project's package with the variables that were stored when the project
+// was created and merges the result into the project. For every file, the package's output at the time of the last
+// creation or update is the base of a three-way merge between the user's current file and the package's new output.
+// Changes that both sides made to the same lines are marked with conflict markers. Plugins don't run during an update.
+// If the update fails, e.g. because it can't be saved, all files are restored and the project is left as it was.
+func (ps projectService) UpdateProject(
+	ctx context.Context, configRootPath string, project *domain.Project, options *domain.UpdateOptions,
+) (report *domain.UpdateReport, err error) {
+	if project.Package == nil {
+		return nil, fmt.Errorf("project %s has no package", project.Path)
+	}
+	if options == nil {
+		options = &domain.UpdateOptions{}
+	}
+
+	newFiles, folders, err := renderPackage(ctx, configRootPath, project, ps.templateSettings)
+	if err != nil {
+		return nil, errors.Wrap(err, "render package")
+	}
+	// Files of applied packages are neither updated nor removed.
+	baseFiles := make(map[string][]byte, len(project.Files))
+	var appliedFiles []*domain.ProjectFile
+	for _, file := range project.Files {
+		if file.AppliedPackage != "" {
+			appliedFiles = append(appliedFiles, file)
+			continue
+		}
+		baseFiles[file.Path] = file.Content
+	}
+
+	// A dry run has no change log, nothing is written then.
+	var changes *changeLog
+	if !options.DryRun {
+		changes = &changeLog{}
+		defer func() {
+			if err == nil {
+				return
+			}
+			rollbackErr := changes.rollback()
+			if rollbackErr != nil {
+				err = errors.Wrapf(err, "roll back update: %v", rollbackErr)
+			}
+		}()
+		for _, folder := range folders {
+			err = changes.mkdirAll(filepath.Join(project.Path, filepath.FromSlash(folder)))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	report = &domain.UpdateReport{}
+	for _, file := range newFiles {
+		base, hasBase := baseFiles[file.path]
+		update, err := mergeFile(changes, project.Path, file, base, hasBase)
+		if err != nil {
+			return nil, errors.Wrapf(err, "update %s", file.path)
+		}
+		report.Files = append(report.Files, update)
+	}
+
+	// Files that the package no longer creates are removed, unless the user changed them.
+	var removedPaths []string
+	for path := range baseFiles {
+		if !newFiles.contains(path) {
+			removedPaths = append(removedPaths, path)
+		}
+	}
+	sort.Strings(removedPaths)
+	for _, path := range removedPaths {
+		update, err := removeFile(changes, project.Path, path, baseFiles[path])
+		if err != nil {
+			return nil, errors.Wrapf(err, "update %s", path)
+		}
+		report.Files = append(report.Files, update)
+	}
+
+	if options.DryRun {
+		return report, nil
+	}
+
+	// The package's new output is the base of the next update. The project is left as it was if that can't be saved,
+	// just like its folder after the rollback.
+	saved := *project
+	defer func() {
+		if err != nil {
+			*project = saved
+		}
+	}()
+	project.PackageID = int(project.Package.ID)
+	project.Variables = updateVariables(project)
+	project.Files = nil
+	for _, file := range newFiles {
+		project.Files = setProjectFile(project.Files, file.path, file.content, true)
+	}
+	for _, file := range appliedFiles {
+		if !newFiles.contains(file.Path) {
+			project.Files = append(project.Files, file)
+		}
+	}
+	if project.Manifest == nil {
+		project.Manifest = newManifest(project, project.Files, nil)
+	} else {
+		updateManifest(project.Manifest, project, project.Files)
+	}
+	err = updateManifestFile(changes, project)
+	if err != nil {
+		return nil, errors.Wrap(err, "write manifest")
+	}
+	err = ps.projectStore.UpdateProject(project)
+	if err != nil {
+		return nil, errors.Wrap(err, "save project")
+	}
+	return report, nil
+}
+
+// renderedFile is a file as the package of a project renders it.
+type renderedFile struct {
+	path    string
+	content []byte
+	perm    os.FileMode
+}
+
+type renderedFiles []*renderedFile
+
+func (rf renderedFiles) contains(path string) bool {
+	for _, file := range rf {
+		if file.path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// renderPackage renders the templates of the project's package in memory and returns the files and folders that they
+// create. Paths are relative to the project's root folder and use forward slashes. Later templates replace files of
+// earlier ones, unless they create empty files, just like during the creation of a project.
+func renderPackage(
+	ctx context.Context, configRootPath string, project *domain.Project, templateSettings *config.Templates,
+) (renderedFiles, []string, error) {
+	variables := updateVariables(project)
+	p := &planner{
+		plan:              &domain.Plan{Project: project},
+		templatesPath:     filepath.Join(configRootPath, "templates"),
+		variables:         variables,
+		templateSettings:  templateSettings,
+		plannedOperations: make(map[string]*domain.Operation),
+		keepFiles:         make(map[*domain.Operation]string),
+	}
+	for _, template := range project.Package.Templates {
+		err := p.addTemplate(template)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	p.addKeepFiles()
+	err := fetchRemoteTemplates(ctx, configRootPath, p.plan.Operations)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var files renderedFiles
+	var folders []string
+	for _, operation := range p.plan.Operations {
+		path := filepath.ToSlash(operation.Destination)
+		file := &renderedFile{path: path, perm: 0o666}
+		switch operation.Kind {
+		case domain.OperationCreateFolder:
+			folders = append(folders, path)
+			continue
+		case domain.OperationCopyFile, domain.OperationRenderFile:
+			var err error
+			file.content, file.perm, err = readTemplate(operation, variables)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		exists := false
+		for i, existing := range files {
+			if existing.path == path {
+				exists = true
+				if isTemplateFile(operation) {
+					files[i] = file
+				}
+			}
+		}
+		if !exists {
+			files = append(files, file)
+		}
+	}
+	return files, folders, nil
+}
+
+// updateVariables returns the variables that the templates of the given project are rendered with during an update.
+// These are the variables stored at the creation of the project, with the base variables reflecting the project's
+// current name, path and package.
+func updateVariables(project *domain.Project) domain.Variables {
+	variables := make(domain.Variables, len(project.Variables))
+	for key, value := range project.Variables {
+		variables[key] = value
+	}
+	for key, value := range newRenderVariables(project, nil) {
+		variables[key] = value
+	}
+	return variables
+}
+
+// mergeFile merges the package's new output for a file into the user's current file. base is the package's output at
+// the time of the last creation or update. Nothing is written without a change log.
+func mergeFile(
+	changes *changeLog, projectPath string, file *renderedFile, base []byte, hasBase bool,
+) (*domain.FileUpdate, error) {
+	update := &domain.FileUpdate{Path: file.path}
+	destination := filepath.Join(projectPath, filepath.FromSlash(file.path))
+	current, err := ioutil.ReadFile(destination)
+	switch {
+	case os.IsNotExist(err) && hasBase:
+		update.Action, update.Reason = domain.UpdateSkipped, "removed from the project"
+		return update, nil
+	case os.IsNotExist(err):
+		update.Action = domain.UpdateCreated
+		return update, writeUpdate(changes, destination, file.content, file.perm)
+	case err != nil:
+		return nil, err
+	}
+
+	switch {
+	case bytes.Equal(current, file.content):
+		update.Action = domain.UpdateUnchanged
+		return update, nil
+	case hasBase && bytes.Equal(current, base):
+		update.Action = domain.UpdateUpdated
+		return update, writeUpdate(changes, destination, file.content, file.perm)
+	case hasBase && bytes.Equal(file.content, base):
+		update.Action, update.Reason = domain.UpdateKept, "changed in the project"
+		return update, nil
+	case !isText(current) || !isText(file.content) || !isText(base):
+		update.Action, update.Reason = domain.UpdateConflict, "binary file changed in the project and the package"
+		return update, nil
+	}
+
+	// Without a base, e.g. for files that existed before the project was created, all differences conflict.
+	merged := diff.Merge(string(base), string(current), string(file.content), conflictLabelCurrent, conflictLabelPackage)
+	update.Action = domain.UpdateMerged
+	if merged.Conflicts > 0 {
+		update.Action, update.Reason = domain.UpdateConflict, fmt.Sprintf("%d conflict(s)", merged.Conflicts)
+	}
+	return update, writeUpdate(changes, destination, []byte(merged.Text), file.perm)
+}
+
+// removeFile removes a file that the package no longer creates, unless the user changed it. Nothing is removed without
+// a change log.
+func removeFile(changes *changeLog, projectPath, path string, base []byte) (*domain.FileUpdate, error) {
+	update := &domain.FileUpdate{Path: path}
+	destination := filepath.Join(projectPath, filepath.FromSlash(path))
+	current, err := ioutil.ReadFile(destination)
+	switch {
+	case os.IsNotExist(err):
+		update.Action, update.Reason = domain.UpdateSkipped, "removed from the project and the package"
+		return update, nil
+	case err != nil:
+		return nil, err
+	case !bytes.Equal(current, base):
+		update.Action, update.Reason = domain.UpdateKept, "removed from the package, but changed in the project"
+		return update, nil
+	}
+	update.Action = domain.UpdateRemoved
+	if changes == nil {
+		return update, nil
+	}
+	return update, changes.removeFile(destination)
+}
+
+// updateManifestFile rewrites the manifest file of the project, if the project has one.
+func updateManifestFile(changes *changeLog, project *domain.Project) error {
+	path := manifestPath(project.Path)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	return writeManifest(changes, project.Path, project.Manifest)
+}
+
+// writeUpdate writes the updated content of a file through the given change log. Existing files keep their
+// permissions.
+func writeUpdate(changes *changeLog, path string, content []byte, perm os.FileMode) error {
+	if changes == nil {
+		return nil
+	}
+	return changes.writeFile(path, content, perm)
+}
@@ -0,0 +1,278 @@
+package projectservice
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nikoksr/proji/internal/config"
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// projectStoreStub records the projects that are written to it. Writes fail with err, if it is set.
+type projectStoreStub struct {
+	domain.ProjectStore
+	stored   []*domain.Project
+	replaced []*domain.Project
+	updated  []*domain.Project
+	err      error
+}
+
+func (s *projectStoreStub) StoreProject(p *domain.Project) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.stored = append(s.stored, p)
+	return nil
+}
+
+func (s *projectStoreStub) ReplaceProject(p *domain.Project) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.replaced = append(s.replaced, p)
+	return nil
+}
+
+func (s *projectStoreStub) UpdateProject(p *domain.Project) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.updated = append(s.updated, p)
+	return nil
+}
+
+func (s *projectStoreStub) UpdateProjectDetails(p *domain.Project) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.updated = append(s.updated, p)
+	return nil
+}
+
+func (s *projectStoreStub) AddProjectPackage(p *domain.Project, _ *domain.Package) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.updated = append(s.updated, p)
+	return nil
+}
+
+func TestUpdateProject(t *testing.T) {
+	// Each file describes the package's output at the last update (base), the file in the project (current) and the
+	// package's new output (template). Empty strings mean that the file doesn't exist.
+	files := []struct {
+		path, base, current, template string
+		action                        string
+		want                          string
+	}{
+		{path: "created.txt", template: "new\n", action: domain.UpdateCreated, want: "new\n"},
+		{path: "updated.txt", base: "v1\n", current: "v1\n", template: "v2\n", action: domain.UpdateUpdated, want: "v2\n"},
+		{path: "kept.txt", base: "v1\n", current: "mine\n", template: "v1\n", action: domain.UpdateKept, want: "mine\n"},
+		{
+			path:     "merged.txt",
+			base:     "a\nb\nc\n",
+			current:  "A\nb\nc\n",
+			template: "a\nb\nC\n",
+			action:   domain.UpdateMerged,
+			want:     "A\nb\nC\n",
+		},
+		{
+			path:     "conflict.txt",
+			base:     "v1\n",
+			current:  "mine\n",
+			template: "theirs\n",
+			action:   domain.UpdateConflict,
+			want:     "<<<<<<< " + conflictLabelCurrent + "\nmine\n=======\ntheirs\n>>>>>>> " + conflictLabelPackage + "\n",
+		},
+		{
+			path:     "binary.bin",
+			base:     "\x00v1",
+			current:  "\x00mine",
+			template: "\x00theirs",
+			action:   domain.UpdateConflict,
+			want:     "\x00mine",
+		},
+		{path: "unchanged.txt", base: "v1\n", current: "v2\n", template: "v2\n", action: domain.UpdateUnchanged, want: "v2\n"},
+		{path: "skipped.txt", base: "v1\n", template: "v2\n", action: domain.UpdateSkipped},
+		{path: "removed.txt", base: "v1\n", current: "v1\n", action: domain.UpdateRemoved},
+		{path: "kept-on-removal.txt", base: "v1\n", current: "mine\n", action: domain.UpdateKept, want: "mine\n"},
+	}
+
+	setup := func(t *testing.T) (string, *domain.Project) {
+		configRootPath, err := ioutil.TempDir("", "proji-update-test-")
+		if err != nil {
+			t.Fatal(err)
+		}
+		templates := make(map[string]string)
+		current := make(map[string]string)
+		pkg := domain.NewPackage("test", "tst")
+		pkg.Templates = []*domain.Template{{IsFile: false, Destination: "docs"}}
+		project := domain.NewProject("example", filepath.Join(configRootPath, "example"), pkg)
+		for _, file := range files {
+			if file.template != "" {
+				templates[file.path] = file.template
+				pkg.Templates = append(pkg.Templates, &domain.Template{IsFile: true, Destination: file.path, Path: file.path})
+			}
+			if file.current != "" {
+				current[file.path] = file.current
+			}
+			if file.base != "" {
+				project.Files = append(project.Files, &domain.ProjectFile{Path: file.path, Content: []byte(file.base)})
+			}
+		}
+		writeTestFiles(t, filepath.Join(configRootPath, "templates"), templates)
+		writeTestFiles(t, project.Path, current)
+		return configRootPath, project
+	}
+
+	for _, dryRun := range []bool{false, true} {
+		name := "Test update"
+		if dryRun {
+			name = "Test dry run"
+		}
+		t.Run(name, func(t *testing.T) {
+			configRootPath, project := setup(t)
+			defer os.RemoveAll(configRootPath)
+			baseFiles := project.Files
+
+			store := &projectStoreStub{}
+			ps := projectService{templateSettings: &config.Templates{}, projectStore: store}
+			report, err := ps.UpdateProject(context.Background(), configRootPath, project, &domain.UpdateOptions{DryRun: dryRun})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			actions := make(map[string]string, len(report.Files))
+			for _, update := range report.Files {
+				actions[update.Path] = update.Action
+			}
+			for _, file := range files {
+				assert.Equal(t, file.action, actions[file.path], "action of %s", file.path)
+
+				content, err := ioutil.ReadFile(filepath.Join(project.Path, file.path))
+				want := file.want
+				if dryRun {
+					want = file.current
+				}
+				if want == "" {
+					assert.True(t, os.IsNotExist(err), "%s should not exist", file.path)
+					continue
+				}
+				if assert.NoError(t, err) {
+					assert.Equal(t, want, string(content), "content of %s", file.path)
+				}
+			}
+
+			_, err = os.Stat(filepath.Join(project.Path, "docs"))
+			if dryRun {
+				// A dry run touches neither the disk nor the database.
+				assert.True(t, os.IsNotExist(err), "docs should not exist")
+				assert.Empty(t, store.updated)
+				assert.Equal(t, baseFiles, project.Files)
+				return
+			}
+
+			// The package's new output is the base of the next update.
+			assert.NoError(t, err)
+			assert.Equal(t, []*domain.Project{project}, store.updated)
+			recorded := make(map[string]string, len(project.Files))
+			for _, file := range project.Files {
+				recorded[file.Path] = string(file.Content)
+			}
+			for _, file := range files {
+				if file.template == "" {
+					assert.NotContains(t, recorded, file.path)
+				} else {
+					assert.Equal(t, file.template, recorded[file.path], "recorded content of %s", file.path)
+				}
+			}
+		})
+	}
+
+	t.Run("Test failing store", func(t *testing.T) {
+		configRootPath, project := setup(t)
+		defer os.RemoveAll(configRootPath)
+		baseFiles := project.Files
+
+		store := &projectStoreStub{err: errors.New("database is locked")}
+		ps := projectService{templateSettings: &config.Templates{}, projectStore: store}
+		_, err := ps.UpdateProject(context.Background(), configRootPath, project, nil)
+		assert.Error(t, err)
+
+		// Everything is rolled back.
+		for _, file := range files {
+			path := filepath.Join(project.Path, file.path)
+			if file.current == "" {
+				assert.NoFileExists(t, path)
+			} else {
+				assertFileContent(t, path, file.current)
+			}
+		}
+		assert.NoDirExists(t, filepath.Join(project.Path, "docs"))
+		assert.Equal(t, baseFiles, project.Files)
+	})
+}
+
+func TestRenderPackage(t *testing.T) {
+	configRootPath, err := ioutil.TempDir("", "proji-render-package-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(configRootPath)
+
+	writeTestFiles(t, filepath.Join(configRootPath, "templates"), map[string]string{
+		"readme.md":        "# {{name}}\n",
+		"docs/index.md":    "{{ name }} docs\n",
+		"docs/api/api.md":  "api\n",
+		"other/readme.md":  "# other\n",
+		"other/license.md": "license\n",
+	})
+	pkg := domain.NewPackage("test", "tst")
+	pkg.Templates = []*domain.Template{
+		{IsFile: true, Destination: "README.md", Path: "readme.md", Render: true},
+		{IsFile: false, Destination: "docs", Path: "docs"},
+		{IsFile: true, Destination: "README.md", Path: ""},
+		{IsFile: true, Destination: "LICENSE", Path: ""},
+		{IsFile: true, Destination: "LICENSE", Path: "other/license.md"},
+	}
+	project := domain.NewProject("example", filepath.Join(configRootPath, "example"), pkg)
+
+	files, folders, err := renderPackage(context.Background(), configRootPath, project, &config.Templates{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	got := make(map[string]string, len(files))
+	for _, file := range files {
+		got[file.path] = string(file.content)
+	}
+	// Empty files never replace files of earlier templates, but templates with content replace earlier empty files.
+	// Only the readme opted in to rendering.
+	assert.Equal(t, map[string]string{
+		"README.md":       "# example\n",
+		"docs/index.md":   "{{ name }} docs\n",
+		"docs/api/api.md": "api\n",
+		"LICENSE":         "license\n",
+	}, got)
+	assert.Equal(t, []string{"docs", "docs/api"}, folders)
+}
+
+// writeTestFiles writes the given files, mapped by their slash separated paths relative to root, to disk.
+func writeTestFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		path = filepath.Join(root, filepath.FromSlash(path))
+		err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(path, []byte(content), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
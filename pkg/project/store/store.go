@@ -1,6 +1,8 @@
 package projectstore
 
 import (
+	"sync"
+
 	"github.com/nikoksr/proji/pkg/domain"
 	"github.com/pkg/errors"
 	"gorm.io/gorm"
@@ -9,6 +11,10 @@ import (
 
 type projectStore struct {
 	db *gorm.DB
+
+	// mu serializes writes to the database, since projects may be created in parallel and SQLite only allows a single
+	// writer at a time.
+	mu sync.Mutex
 }
 
 func New(db *gorm.DB) domain.ProjectStore {
@@ -18,6 +24,9 @@ func New(db *gorm.DB) domain.ProjectStore {
 }
 
 func (ps *projectStore) StoreProject(project *domain.Project) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
 	err := ps.db.Where("path = ?", project.Path).First(project).Error
 	if err == nil {
 		return ErrProjectExists
@@ -37,27 +46,112 @@ func (ps *projectStore) StoreProject(project *domain.Project) error {
 		return tx.Error
 	}
 
-	err = tx.Omit(clause.Associations).Create(project).Error
+	err = insertProject(tx, project)
 	if err != nil {
 		tx.Rollback()
-		return errors.Wrap(err, "insert project")
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// ReplaceProject removes the project that's stored for the path of the given project, if there is one, and stores the
+// given project in its place. Both happen in the same transaction, so the old project stays stored if the new one can't
+// be stored.
+func (ps *projectStore) ReplaceProject(project *domain.Project) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	tx := ps.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+	if tx.Error != nil {
+		tx.Rollback()
+		return tx.Error
 	}
 
+	err := removeProject(tx, project.Path)
+	if err != nil && !errors.Is(err, ErrProjectNotFound) {
+		tx.Rollback()
+		return err
+	}
+	project.ID = 0
+	err = insertProject(tx, project)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
 	return tx.Commit().Error
 }
 
+// insertProject inserts the given project together with its files and its manifest.
+func insertProject(tx *gorm.DB, project *domain.Project) error {
+	err := tx.Omit(clause.Associations).Create(project).Error
+	if err != nil {
+		return errors.Wrap(err, "insert project")
+	}
+	err = insertProjectFiles(tx, project)
+	if err != nil {
+		return err
+	}
+	return saveManifest(tx, project)
+}
+
+// saveManifest inserts or updates the manifest of the given project.
+func saveManifest(tx *gorm.DB, project *domain.Project) error {
+	if project.Manifest == nil {
+		return nil
+	}
+	project.Manifest.ProjectID = project.ID
+	err := tx.Save(project.Manifest).Error
+	if err != nil {
+		return errors.Wrap(err, "save manifest")
+	}
+	return nil
+}
+
+// insertProjectFiles inserts the files of the given project.
+func insertProjectFiles(tx *gorm.DB, project *domain.Project) error {
+	if len(project.Files) == 0 {
+		return nil
+	}
+	for _, file := range project.Files {
+		file.ID = 0
+		file.ProjectID = project.ID
+	}
+	err := tx.Create(&project.Files).Error
+	if err != nil {
+		return errors.Wrap(err, "insert project files")
+	}
+	return nil
+}
+
 func (ps *projectStore) LoadProject(path string) (*domain.Project, error) {
 	var project domain.Project
-	tx := ps.db.Where("path = ?", path).First(&project)
+	tx := ps.db.Preload("Package.Plugins").
+		Preload("AppliedPackages").
+		Preload("Files").
+		Preload("Manifest").
+		Where("path = ?", path).
+		First(&project)
 	if errors.Is(tx.Error, gorm.ErrRecordNotFound) || tx.RowsAffected < 1 {
 		return nil, ErrProjectNotFound
 	}
-	return &project, tx.Error
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	err := ps.loadPluginSettings(project.Package)
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
 }
 
 func (ps *projectStore) LoadProjectList(paths ...string) ([]*domain.Project, error) {
 	numPaths := len(paths)
-	if numPaths < 1 && paths != nil {
+	if numPaths < 1 {
 		return ps.loadAllProjects()
 	}
 	projects := make([]*domain.Project, 0, numPaths)
@@ -73,14 +167,83 @@ func (ps *projectStore) LoadProjectList(paths ...string) ([]*domain.Project, err
 
 func (ps *projectStore) loadAllProjects() ([]*domain.Project, error) {
 	var projects []*domain.Project
-	err := ps.db.Preload(clause.Associations).Find(&projects).Error
+	err := ps.db.Preload("Package.Plugins").Preload("AppliedPackages").Find(&projects).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, ErrNoProjectsFound
 	}
-	return projects, err
+	if err != nil {
+		return nil, err
+	}
+	for _, project := range projects {
+		err = ps.loadPluginSettings(project.Package)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return projects, nil
+}
+
+// loadPluginSettings sets the type, the timeout and the hook of the preloaded plugins of the given package. They're
+// stored with the package's plugin associations, so preloading doesn't set them. The plugins are copied, since packages
+// share them.
+func (ps *projectStore) loadPluginSettings(pkg *domain.Package) error {
+	if pkg == nil || len(pkg.Plugins) == 0 {
+		return nil
+	}
+	var associations []*domain.PackagePlugin
+	err := ps.db.Where("package_id = ?", pkg.ID).Find(&associations).Error
+	if err != nil {
+		return errors.Wrap(err, "load plugin settings")
+	}
+	settings := make(map[uint]*domain.PackagePlugin, len(associations))
+	for _, association := range associations {
+		settings[association.PluginID] = association
+	}
+	plugins := make([]*domain.Plugin, 0, len(pkg.Plugins))
+	for _, plugin := range pkg.Plugins {
+		plugin := *plugin
+		if association, ok := settings[plugin.ID]; ok {
+			plugin.Type = association.Type
+			plugin.Timeout = association.Timeout
+			plugin.Hook = association.Hook
+		}
+		plugins = append(plugins, &plugin)
+	}
+	pkg.Plugins = plugins
+	return nil
 }
 
 func (ps *projectStore) UpdateProjectLocation(oldPath, newPath string) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	tx := ps.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	result := tx.Model(&domain.Project{}).Where("path = ?", oldPath).Update("path", newPath)
+	if result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		tx.Rollback()
+		return ErrProjectNotFound
+	}
+	return tx.Commit().Error
+}
+
+// UpdateProject updates the package, the variables, the files and the manifest of the given project.
+func (ps *projectStore) UpdateProject(project *domain.Project) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
 	tx := ps.db.Begin()
 	defer func() {
 		if r := recover(); r != nil {
@@ -91,11 +254,26 @@ func (ps *projectStore) UpdateProjectLocation(oldPath, newPath string) error {
 		return err
 	}
 
-	err := tx.Model(&domain.Project{Path: oldPath}).Update("path", newPath).Error
-	if errors.Is(err, gorm.ErrRecordNotFound) || tx.RowsAffected < 1 {
+	result := tx.Model(project).Omit(clause.Associations).Select("package_id", "variables").Updates(project)
+	if result.Error != nil {
+		tx.Rollback()
+		return errors.Wrap(result.Error, "update project")
+	}
+	if result.RowsAffected < 1 {
 		tx.Rollback()
 		return ErrProjectNotFound
 	}
+	err := tx.Where("project_id = ?", project.ID).Delete(&domain.ProjectFile{}).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "remove project files")
+	}
+	err = insertProjectFiles(tx, project)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = saveManifest(tx, project)
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -103,7 +281,27 @@ func (ps *projectStore) UpdateProjectLocation(oldPath, newPath string) error {
 	return tx.Commit().Error
 }
 
-func (ps *projectStore) RemoveProject(path string) error {
+// UpdateProjectDetails updates the tags, the description and the notes of the given project.
+func (ps *projectStore) UpdateProjectDetails(project *domain.Project) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	result := ps.db.Model(project).Omit(clause.Associations).Select("tags", "description", "notes").Updates(project)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "update project details")
+	}
+	if result.RowsAffected < 1 {
+		return ErrProjectNotFound
+	}
+	return nil
+}
+
+// AddProjectPackage records that the given package was applied to the given project and updates the files and the
+// manifest of the project.
+func (ps *projectStore) AddProjectPackage(project *domain.Project, pkg *domain.Package) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
 	tx := ps.db.Begin()
 	defer func() {
 		if r := recover(); r != nil {
@@ -113,14 +311,75 @@ func (ps *projectStore) RemoveProject(path string) error {
 	if err := tx.Error; err != nil {
 		return err
 	}
-	err := tx.Set("gorm:delete_option", "OPTION (OPTIMIZE FOR UNKNOWN)").Where("path = ?", path).Delete(&domain.Project{}).Error
-	if errors.Is(err, gorm.ErrRecordNotFound) {
+
+	err := tx.Exec("INSERT INTO project_packages (project_id, package_id) VALUES (?, ?)", project.ID, pkg.ID).Error
+	if err != nil {
 		tx.Rollback()
-		return ErrProjectNotFound
+		return errors.Wrap(err, "insert project package")
 	}
+	err = tx.Where("project_id = ?", project.ID).Delete(&domain.ProjectFile{}).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "remove project files")
+	}
+	err = insertProjectFiles(tx, project)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = saveManifest(tx, project)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = tx.Commit().Error
+	if err != nil {
+		return err
+	}
+	project.AppliedPackages = append(project.AppliedPackages, pkg)
+	return nil
+}
+
+func (ps *projectStore) RemoveProject(path string) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	tx := ps.db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+	if err := tx.Error; err != nil {
+		return err
+	}
+	err := removeProject(tx, path)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 	return tx.Commit().Error
 }
+
+// removeProject removes the project with the given path together with its files, its manifest and its applied
+// packages.
+func removeProject(tx *gorm.DB, path string) error {
+	projectIDs := tx.Model(&domain.Project{}).Select("id").Where("path = ?", path)
+	err := tx.Where("project_id IN (?)", projectIDs).Delete(&domain.ProjectFile{}).Error
+	if err != nil {
+		return errors.Wrap(err, "remove project files")
+	}
+	err = tx.Where("project_id IN (?)", projectIDs).Delete(&domain.Manifest{}).Error
+	if err != nil {
+		return errors.Wrap(err, "remove manifest")
+	}
+	err = tx.Exec("DELETE FROM project_packages WHERE project_id IN (?)", projectIDs).Error
+	if err != nil {
+		return errors.Wrap(err, "remove project packages")
+	}
+	result := tx.Set("gorm:delete_option", "OPTION (OPTIMIZE FOR UNKNOWN)").Where("path = ?", path).Delete(&domain.Project{})
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return ErrProjectNotFound
+	}
+	return result.Error
+}
@@ -0,0 +1,128 @@
+package projectstore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nikoksr/proji/internal/database"
+	"github.com/nikoksr/proji/pkg/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestStore returns a project store on top of a new database in the given folder.
+func newTestStore(t *testing.T, dir string) (domain.ProjectStore, *database.Database) {
+	t.Helper()
+	db, err := database.New("sqlite3", filepath.Join(dir, "proji.sqlite3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Migrate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(db.Connection), db
+}
+
+func TestReplaceProject(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "proji-project-store-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+	store, db := newTestStore(t, tempDir)
+
+	path := filepath.Join(tempDir, "example")
+	old := domain.NewProject("old", path, nil)
+	old.Files = []*domain.ProjectFile{{Path: "old.txt", Content: []byte("old\n")}}
+	err = store.StoreProject(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Replacing a project removes the old one with its files.
+	replacement := domain.NewProject("new", path, nil)
+	replacement.Files = []*domain.ProjectFile{{Path: "new.txt", Content: []byte("new\n")}}
+	if !assert.NoError(t, store.ReplaceProject(replacement)) {
+		return
+	}
+	project, err := store.LoadProject(path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "new", project.Name)
+		if assert.Len(t, project.Files, 1) {
+			assert.Equal(t, "new.txt", project.Files[0].Path)
+		}
+	}
+	var files int64
+	assert.NoError(t, db.Connection.Model(&domain.ProjectFile{}).Count(&files).Error)
+	assert.Equal(t, int64(1), files)
+
+	// A project that isn't stored yet is simply stored.
+	other := domain.NewProject("other", filepath.Join(tempDir, "other"), nil)
+	assert.NoError(t, store.ReplaceProject(other))
+	_, err = store.LoadProject(other.Path)
+	assert.NoError(t, err)
+}
+
+func TestUpdateProjectDetails(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "proji-project-store-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+	store, _ := newTestStore(t, tempDir)
+
+	path := filepath.Join(tempDir, "shop")
+	err = store.StoreProject(domain.NewProject("shop", path, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		tags        domain.StringList
+		description string
+		notes       string
+	}{
+		{
+			name:        "Test set details",
+			tags:        domain.StringList{"client:acme", "status:active"},
+			description: "Online shop of ACME",
+			notes:       "On hold\nAsk Kim about the staging credentials",
+		},
+		{name: "Test clear tags", description: "Online shop of ACME", notes: "On hold"},
+		{name: "Test clear description", tags: domain.StringList{"archived"}, notes: "On hold"},
+		{name: "Test clear notes", tags: domain.StringList{"archived"}, description: "Online shop of ACME"},
+		{name: "Test clear all"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project, err := store.LoadProject(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			project.Tags = tt.tags
+			project.Description = tt.description
+			project.Notes = tt.notes
+			if !assert.NoError(t, store.UpdateProjectDetails(project)) {
+				return
+			}
+
+			project, err = store.LoadProject(path)
+			if assert.NoError(t, err) {
+				assert.ElementsMatch(t, tt.tags, project.Tags)
+				assert.Equal(t, tt.description, project.Description)
+				assert.Equal(t, tt.notes, project.Notes)
+				assert.Equal(t, "shop", project.Name)
+			}
+		})
+	}
+
+	t.Run("Test unknown project", func(t *testing.T) {
+		project := domain.NewProject("unknown", filepath.Join(tempDir, "unknown"), nil)
+		project.ID = 1000
+		project.Description = "unknown"
+		assert.Equal(t, ErrProjectNotFound, store.UpdateProjectDetails(project))
+	})
+}